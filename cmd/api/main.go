@@ -17,7 +17,10 @@ func main() {
 	}
 
 	// Set up API handlers
-	router := api.SetupRouter(cfg)
+	router, err := api.SetupRouter(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up router: %v", err)
+	}
 
 	// Start the server
 	port := os.Getenv("PORT")
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+const (
+	defaultMaxLookups = 100
+	maxMaxLookups     = 500
+)
+
+// accountCounterparty is a counterparty to classify, with the chains it was seen on
+// in a cross-chain analysis
+type accountCounterparty struct {
+	address         string
+	chains          map[int]*models.ChainSubtotal
+	createdByTarget bool // The target created the counterparty in one of the transactions
+}
+
+// untyped returns params without the counterparty_type filter, selecting the counterparties
+// to classify before their account types are known
+func untyped(params FilterAndSortParams) FilterAndSortParams {
+	params.CounterpartyTypes = nil
+	return params
+}
+
+// rejectAccountFilters checks that no label or account type parameters are given to an
+// endpoint whose results are not filtered by them
+func rejectAccountFilters(params FilterAndSortParams) error {
+	if len(params.Categories) > 0 || len(params.ExcludeCategories) > 0 || params.AccountTypes {
+		return errors.New("category, exclude_category, account_types and counterparty_type are not supported by this endpoint")
+	}
+	return nil
+}
+
+// parseAccountTypes parses account types, given as repeated or comma-separated values
+func parseAccountTypes(values []string) ([]string, error) {
+	var types []string
+	for _, value := range values {
+		for _, accountType := range strings.Split(value, ",") {
+			accountType = strings.ToLower(strings.TrimSpace(accountType))
+			if accountType == "" {
+				continue
+			}
+			if !slices.Contains(models.AccountTypes, accountType) {
+				return nil, fmt.Errorf("invalid counterparty_type %q, expected one of %s", accountType, strings.Join(models.AccountTypes, ", "))
+			}
+			types = append(types, accountType)
+		}
+	}
+	return types, nil
+}
+
+// matchesAccountTypeFilter reports whether a counterparty of the given account type passes
+// the counterparty_type filter
+func matchesAccountTypeFilter(accountType string, params FilterAndSortParams) bool {
+	return len(params.CounterpartyTypes) == 0 || slices.Contains(params.CounterpartyTypes, accountType)
+}
+
+// accountTypes classifies counterparties if account types are requested, each on the chains
+// it was seen on or on the requested chain. It returns nil otherwise. The counterparties are those
+// passing the amount and label filters, in the sort order; only the first params.MaxLookups of them
+// are looked up and the others are reported in status. In partial mode a failed lookup is reported
+// in status too, leaving every counterparty unclassified.
+func (h *Handler) accountTypes(ctx context.Context, params FilterAndSortParams, counterparties []accountCounterparty, status *models.FetchStatus) (map[string]string, error) {
+	if !params.AccountTypes {
+		return nil, nil
+	}
+
+	// Contracts the target was seen creating need no lookup
+	created := map[string]bool{}
+	var lookup []accountCounterparty
+	for _, counterparty := range counterparties {
+		if counterparty.createdByTarget {
+			created[strings.ToLower(counterparty.address)] = true
+		} else {
+			lookup = append(lookup, counterparty)
+		}
+	}
+	if len(lookup) > params.MaxLookups {
+		status.Complete = false
+		status.Warnings = append(status.Warnings, models.Warning{
+			TransactionType: "account types",
+			Error:           fmt.Sprintf("%d of %d counterparties left unclassified, beyond max_lookups", len(lookup)-params.MaxLookups, len(lookup)),
+		})
+		lookup = lookup[:params.MaxLookups]
+	}
+
+	addresses := map[int][]string{}
+	for _, counterparty := range lookup {
+		if len(counterparty.chains) == 0 {
+			addresses[params.ChainId] = append(addresses[params.ChainId], counterparty.address)
+			continue
+		}
+		for chainId := range counterparty.chains {
+			addresses[chainId] = append(addresses[chainId], counterparty.address)
+		}
+	}
+
+	types, err := h.analysisService.ClassifyAccounts(ctx, params.Source, params.ApiKey, params.Address, addresses)
+	if err != nil && params.Partial && ctx.Err() == nil {
+		status.Complete = false
+		status.Warnings = append(status.Warnings, models.Warning{TransactionType: "account types", Error: err.Error()})
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for address := range created {
+		types[address] = models.AccountCreatedByTarget
+	}
+	return types, nil
+}
@@ -0,0 +1,313 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/utils"
+)
+
+// batchLimits bounds the work of a single batch request
+type batchLimits struct {
+	concurrency  int // Addresses analyzed concurrently
+	maxAddresses int // Addresses accepted in one batch
+}
+
+// batchEntity is a counterparty found for one address of a batch
+type batchEntity struct {
+	address     string
+	amount      utils.Amount
+	assets      map[string]*models.AssetAmount
+	labels      []models.Label
+	accountType string
+	txCount     int
+}
+
+// batchOutcome is the analysis of one address of a batch
+type batchOutcome struct {
+	data      any           // Filtered and limited counterparties, as returned by the endpoint
+	entities  []batchEntity // Every counterparty passing the filters, ignoring the limit
+	screening *models.Screening
+	status    models.FetchStatus
+	err       error
+}
+
+// batchAnalyses are the analyses accepted by POST /batch. They return the counterparties that
+// pass the filters twice: limited for the per-address results, and unlimited for the shared view.
+var batchAnalyses = map[string]func(h *Handler, ctx context.Context, params FilterAndSortParams) batchOutcome{
+	"beneficiary": func(h *Handler, ctx context.Context, params FilterAndSortParams) batchOutcome {
+		beneficiaries, status, err := h.analyzeBeneficiaries(ctx, params)
+		if err != nil {
+			return batchOutcome{err: err}
+		}
+
+		filtered := filterBeneficiaries(beneficiaries, unlimited(params))
+		entities := make([]batchEntity, 0, len(filtered))
+		for _, ben := range filtered {
+			entities = append(entities, batchEntity{ben.Address, ben.Amount, ben.Assets, ben.Labels, ben.AccountType, len(ben.Transactions)})
+		}
+		return batchOutcome{
+			data:      filtered[:min(len(filtered), params.Limit)],
+			entities:  entities,
+			screening: h.screener.Screen(params.Address, beneficiaryExposures(beneficiaries)),
+			status:    status,
+		}
+	},
+	"payer": func(h *Handler, ctx context.Context, params FilterAndSortParams) batchOutcome {
+		payers, status, err := h.analyzePayers(ctx, params)
+		if err != nil {
+			return batchOutcome{err: err}
+		}
+
+		filtered := filterPayers(payers, unlimited(params))
+		entities := make([]batchEntity, 0, len(filtered))
+		for _, payer := range filtered {
+			entities = append(entities, batchEntity{payer.Address, payer.Amount, payer.Assets, payer.Labels, payer.AccountType, len(payer.Transactions)})
+		}
+		return batchOutcome{
+			data:      filtered[:min(len(filtered), params.Limit)],
+			entities:  entities,
+			screening: h.screener.Screen(params.Address, payerExposures(payers)),
+			status:    status,
+		}
+	},
+}
+
+// unlimited returns params without a result limit
+func unlimited(params FilterAndSortParams) FilterAndSortParams {
+	params.Limit = math.MaxInt
+	return params
+}
+
+// parseBatchAddresses extracts the addresses of a batch, given as repeated or comma-separated
+// values, dropping duplicates while keeping the order they were given in
+func parseBatchAddresses(query url.Values, maxAddresses int) ([]string, error) {
+	var addresses []string
+	seen := map[string]bool{}
+	for _, value := range query["addresses"] {
+		for _, address := range strings.Split(value, ",") {
+			address = strings.TrimSpace(address)
+			if address == "" {
+				continue
+			}
+			if err := validateAddress(address); err != nil {
+				return nil, fmt.Errorf("%s: %w", address, err)
+			}
+			if seen[strings.ToLower(address)] {
+				continue
+			}
+			seen[strings.ToLower(address)] = true
+			addresses = append(addresses, address)
+		}
+	}
+
+	if len(addresses) == 0 {
+		return nil, errors.New("addresses parameter is required")
+	}
+	if maxAddresses > 0 && len(addresses) > maxAddresses {
+		return nil, fmt.Errorf("a batch holds at most %d addresses, got %d", maxAddresses, len(addresses))
+	}
+	return addresses, nil
+}
+
+// BatchHandler handles requests to the /batch endpoint, which runs the beneficiary or payer
+// analysis of many addresses with shared filters and finds the counterparties they share
+func (h *Handler) BatchHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	// Validate HTTP method
+	if !helper.ensureMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	query, err := parseBodyQuery(r)
+	if err != nil {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid batch parameters: "+err.Error())
+		return
+	}
+
+	// Look up the analysis
+	name := strings.ToLower(query.Get("analysis"))
+	analyze, ok := batchAnalyses[name]
+	if !ok {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters",
+			fmt.Sprintf("Invalid batch parameters: invalid analysis %q, expected beneficiary or payer", name))
+		return
+	}
+
+	// Parse and validate addresses
+	addresses, err := parseBatchAddresses(query, h.batch.maxAddresses)
+	if err != nil {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_address", "Invalid batch addresses: "+err.Error())
+		return
+	}
+
+	// Parse and validate the shared parameters
+	params, err := parseQueryParams(query)
+	if err == nil {
+		err = checkTimeout(params, h.requestTimeout)
+	}
+	if err != nil {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	minShared := 2
+	if minSharedStr := query.Get("min_shared"); minSharedStr != "" {
+		if minShared, err = strconv.Atoi(minSharedStr); err != nil || minShared < 1 {
+			helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid query parameters: min_shared must be a positive integer")
+			return
+		}
+	}
+
+	// Analyze the addresses, at most h.batch.concurrency at a time. Every fetch goes
+	// through the client's rate limiter, so the batch shares the Etherscan rate limit,
+	// and one deadline bounds the whole batch, including addresses waiting for a slot.
+	ctx, cancel := h.requestContext(r, params)
+	defer cancel()
+
+	outcomes := make([]batchOutcome, len(addresses))
+	slots := make(chan struct{}, max(h.batch.concurrency, 1))
+	var wg sync.WaitGroup
+	for i, address := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				outcomes[i] = batchOutcome{err: ctx.Err()}
+				return
+			}
+
+			addressParams := params
+			addressParams.Address = address
+			outcomes[i] = analyze(h, ctx, addressParams)
+		}()
+	}
+	wg.Wait()
+
+	// Collect the per-address results
+	response := models.BatchResponse{
+		Message:  "success",
+		Analysis: name,
+		Complete: true,
+		Results:  make([]models.BatchResult, 0, len(addresses)),
+	}
+	var errs []error
+	for i, outcome := range outcomes {
+		result := models.BatchResult{Address: addresses[i], FetchStatus: outcome.status, Data: outcome.data, Screening: outcome.screening}
+		if outcome.err != nil {
+			_, detail := classifyAnalysisError(outcome.err, "Failed to analyze "+addresses[i])
+			result.Error = &detail
+			errs = append(errs, fmt.Errorf("%s: %w", addresses[i], outcome.err))
+		}
+		if !result.Complete {
+			response.Complete = false
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	if len(errs) == len(addresses) {
+		helper.respondWithAnalysisError(w, fmt.Errorf("every address failed: %w", errors.Join(errs...)), "Failed to analyze batch")
+		return
+	}
+
+	response.Shared = sharedCounterparties(addresses, outcomes, minShared, params)
+
+	// Send JSON response
+	helper.respondWithJSON(w, response)
+}
+
+// sharedCounterparties merges the counterparties that at least minShared addresses of the batch
+// transacted with, ordered by how many addresses share them and then by amount, up to params.Limit.
+// Addresses of the batch that are counterparties of each other count too.
+func sharedCounterparties(addresses []string, outcomes []batchOutcome, minShared int, params FilterAndSortParams) []models.SharedCounterparty {
+	merged := map[string]*models.SharedCounterparty{}
+	var order []string
+
+	for i, outcome := range outcomes {
+		for _, entity := range outcome.entities {
+			key := strings.ToLower(entity.address)
+			shared, exists := merged[key]
+			if !exists {
+				shared = &models.SharedCounterparty{
+					Address: entity.address,
+					Amount:  utils.NewAmount(nil, entity.amount.Decimals()),
+					Assets:  map[string]*models.AssetAmount{},
+					Labels:  entity.labels,
+				}
+				merged[key] = shared
+				order = append(order, key)
+			}
+
+			// A contract created by any address of the batch counts as created by the target
+			if shared.AccountType == "" || entity.accountType != "" &&
+				slices.Index(models.AccountTypes, entity.accountType) < slices.Index(models.AccountTypes, shared.AccountType) {
+				shared.AccountType = entity.accountType
+			}
+			shared.Addresses = append(shared.Addresses, addresses[i])
+			shared.Amount = shared.Amount.Add(entity.amount)
+			shared.TxCount += entity.txCount
+			for assetKey, a := range entity.assets {
+				total, exists := shared.Assets[assetKey]
+				copied := *a
+
+				// A contract may be an ERC-20 token for one address and only NFTs for another,
+				// which are keyed apart as they do not share decimals
+				if exists && total.Decimals != a.Decimals {
+					if a.Standard == models.TransferERC20 {
+						delete(shared.Assets, assetKey)
+						total.Key = models.NFTAssetKey(total.Key, total.Standard)
+						shared.Assets[models.NFTAssetKey(assetKey, total.Standard)] = total
+					} else {
+						assetKey = models.NFTAssetKey(assetKey, a.Standard)
+						copied.Key = models.NFTAssetKey(a.Key, a.Standard)
+					}
+					total, exists = shared.Assets[assetKey]
+				}
+
+				if !exists {
+					shared.Assets[assetKey] = &copied
+					continue
+				}
+				total.Amount = total.Amount.Add(a.Amount)
+				total.TxCount += a.TxCount
+				total.FirstTimestamp = min(total.FirstTimestamp, a.FirstTimestamp)
+				total.LastTimestamp = max(total.LastTimestamp, a.LastTimestamp)
+			}
+		}
+	}
+
+	shared := []models.SharedCounterparty{}
+	for _, key := range order {
+		if len(merged[key].Addresses) >= minShared {
+			shared = append(shared, *merged[key])
+		}
+	}
+
+	sort.SliceStable(shared, func(i, j int) bool {
+		if len(shared[i].Addresses) != len(shared[j].Addresses) {
+			return len(shared[i].Addresses) > len(shared[j].Addresses)
+		}
+		return compareSortAmounts(shared[i].Amount, shared[i].Assets, shared[j].Amount, shared[j].Assets, params) > 0
+	})
+
+	if len(shared) > params.Limit {
+		shared = shared[:params.Limit]
+	}
+
+	return shared
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// ChainsHandler handles requests to the /chains endpoint
+func (h *Handler) ChainsHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	// Validate HTTP method
+	if !helper.ensureMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	// Create the response
+	response := models.ChainsResponse{
+		Message: "success",
+		Data:    h.chains.List(),
+	}
+
+	// Send JSON response
+	helper.respondWithJSON(w, response)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/utils"
+)
+
+// flowMeasures are the sort_by values of /counterparties, each selecting the amount
+// that filters and sorting apply to. "amount" is the total volume in both directions.
+var flowMeasures = map[string]func(flow *models.AssetFlow) utils.Amount{
+	"amount":  func(flow *models.AssetFlow) utils.Amount { return flow.Inflow.Add(flow.Outflow) },
+	"net":     func(flow *models.AssetFlow) utils.Amount { return flow.Net },
+	"inflow":  func(flow *models.AssetFlow) utils.Amount { return flow.Inflow },
+	"outflow": func(flow *models.AssetFlow) utils.Amount { return flow.Outflow },
+}
+
+// CounterpartiesHandler handles requests to the /counterparties endpoint
+func (h *Handler) CounterpartiesHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	// Validate HTTP method
+	if !helper.ensureMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	// Parse and validate parameters
+	params, ok := helper.getValidParams(w, r, h.requestTimeout)
+	if !ok {
+		return
+	}
+
+	if err := validateFlowMeasure(params); err != nil {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	ctx, cancel := h.requestContext(r, params)
+	defer cancel()
+
+	// Get the filtered counterparties
+	response, err := h.counterparties(ctx, params)
+	if err != nil {
+		helper.respondWithAnalysisError(w, err, "Failed to analyze counterparties")
+		return
+	}
+
+	// Send JSON response
+	helper.respondWithJSON(w, response)
+}
+
+// validateFlowMeasure checks that sort_by names one of the flowMeasures
+func validateFlowMeasure(params FilterAndSortParams) error {
+	if _, ok := flowMeasures[params.SortBy]; !ok {
+		return fmt.Errorf("invalid sort_by %q, expected amount, net, inflow or outflow", params.SortBy)
+	}
+	return nil
+}
+
+// counterparties runs the counterparty analysis, labels the counterparties and classifies
+// their accounts if requested, and creates the /counterparties response
+func (h *Handler) counterparties(ctx context.Context, params FilterAndSortParams) (models.CounterpartiesResponse, error) {
+	// Get counterparties from the service
+	counterparties, status, err := h.analysisService.AnalyzeCounterparties(ctx, httpHelper{}.toAnalysisParams(params))
+	if err != nil {
+		return models.CounterpartiesResponse{}, err
+	}
+
+	for i := range counterparties {
+		counterparties[i].Labels = h.labels.Lookup(counterparties[i].Address)
+	}
+
+	// Classify the counterparties that can pass the filters
+	var accountCounterparties []accountCounterparty
+	if params.AccountTypes {
+		for _, c := range filterCounterparties(counterparties, untyped(unlimited(params))) {
+			accountCounterparties = append(accountCounterparties, accountCounterparty{address: c.Address})
+		}
+	}
+	types, err := h.accountTypes(ctx, params, accountCounterparties, &status)
+	if err != nil {
+		return models.CounterpartiesResponse{}, err
+	}
+	for i := range counterparties {
+		counterparties[i].AccountType = types[strings.ToLower(counterparties[i].Address)]
+	}
+
+	// Apply filtering and sorting
+	return models.CounterpartiesResponse{
+		Message:     "success",
+		FetchStatus: status,
+		Data:        filterCounterparties(counterparties, params),
+		Screening:   h.screener.Screen(params.Address, counterpartyExposures(counterparties)),
+	}, nil
+}
+
+// filterCounterparties applies filtering and sorting to counterparties based on the params.
+// The measure selected by sort_by is filtered and sorted on; for the net flow, min and max
+// apply to its absolute value so that net sources and net sinks are treated alike.
+func filterCounterparties(counterparties []models.Counterparty, params FilterAndSortParams) []models.Counterparty {
+	measure := flowMeasures[params.SortBy]
+
+	type measured struct {
+		counterparty models.Counterparty
+		native       utils.Amount
+		assets       map[string]*models.AssetAmount
+	}
+
+	filtered := []measured{}
+
+	// Apply filters
+	for _, c := range counterparties {
+		m := measured{counterparty: c, assets: map[string]*models.AssetAmount{}}
+		absolute := map[string]*models.AssetAmount{}
+		for key, flow := range c.Assets {
+			amount := measure(flow)
+			m.assets[key] = &models.AssetAmount{Asset: flow.Asset, Amount: amount}
+			absolute[key] = &models.AssetAmount{Asset: flow.Asset, Amount: amount.Abs()}
+		}
+		m.native = models.AssetAmountOf(utils.NewAmount(nil, 0), m.assets, models.NativeAsset)
+		nativeAbsolute := models.AssetAmountOf(utils.NewAmount(nil, 0), absolute, models.NativeAsset)
+
+		if !matchesAmountFilters(nativeAbsolute, absolute, params) || !matchesLabelFilters(c.Labels, params) ||
+			!matchesAccountTypeFilter(c.AccountType, params) {
+			continue
+		}
+
+		filtered = append(filtered, m)
+	}
+
+	// Apply sorting
+	sort.SliceStable(filtered, func(i, j int) bool {
+		c := compareSortAmounts(filtered[i].native, filtered[i].assets, filtered[j].native, filtered[j].assets, params)
+		if params.Sort == "asc" {
+			return c < 0
+		}
+		return c > 0
+	})
+
+	// Apply limit
+	if len(filtered) > params.Limit {
+		filtered = filtered[:params.Limit]
+	}
+
+	result := make([]models.Counterparty, 0, len(filtered))
+	for _, m := range filtered {
+		result = append(result, m.counterparty)
+	}
+	return result
+}
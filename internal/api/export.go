@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/export"
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// parseGraphFormat returns the graph export format requested by the format query
+// parameter or, failing that, the Accept header. An empty format means plain JSON.
+func parseGraphFormat(r *http.Request) (string, error) {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		if format == "json" {
+			return "", nil
+		}
+		if !export.IsFormat(format) {
+			return "", fmt.Errorf("invalid format %q, expected json, %s, %s, %s or %s", format,
+				export.FormatGraphML, export.FormatDOT, export.FormatGEXF, export.FormatCytoscape)
+		}
+		return format, nil
+	}
+
+	// Negotiate from the Accept header, in the client's order
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		if format, ok := export.FormatForMediaType(mediaType); ok {
+			return format, nil
+		}
+	}
+
+	return "", nil
+}
+
+// getGraphFormat parses the requested graph export format, responding with an error if it is invalid
+func (h httpHelper) getGraphFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format, err := parseGraphFormat(r)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid query parameters: "+err.Error())
+		return "", false
+	}
+	return format, true
+}
+
+// respondWithGraph sends a flow graph in the given export format
+func (h httpHelper) respondWithGraph(w http.ResponseWriter, format string, graph models.FlowGraph) {
+	w.Header().Set("Content-Type", export.ContentType(format))
+	if err := export.Write(w, format, graph); err != nil {
+		log.Printf("Error exporting graph: %v", err)
+		http.Error(w, "Failed to export graph", http.StatusInternalServerError)
+	}
+}
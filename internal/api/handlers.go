@@ -1,33 +1,140 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"math/big"
 	"net/http"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"Ethereum-fund-flow-analysis/internal/chains"
 	"Ethereum-fund-flow-analysis/internal/client"
 	"Ethereum-fund-flow-analysis/internal/config"
+	"Ethereum-fund-flow-analysis/internal/export"
+	"Ethereum-fund-flow-analysis/internal/jobs"
+	"Ethereum-fund-flow-analysis/internal/labels"
 	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/screening"
 	"Ethereum-fund-flow-analysis/internal/services"
+	"Ethereum-fund-flow-analysis/internal/store"
+	"Ethereum-fund-flow-analysis/internal/utils"
 )
 
 // Handler contains the dependencies needed by the API handlers
 type Handler struct {
 	analysisService *service.AnalysisService
+	chains          *chains.Registry
+	labels          *labels.Registry
+	screener        *screening.Screener
+	jobs            *jobs.Manager
+	requestTimeout  time.Duration // Default deadline of an analysis, 0 for none
+	jobTimeout      time.Duration // Default deadline of a job, 0 for none
+	batch           batchLimits
 }
 
 // NewHandler creates a new API handler
-func NewHandler(cfg *config.Config) *Handler {
-	etherscanClient := client.NewClient(cfg.EtherscanBaseURL, cfg.EtherscanAPIKey)
-	analysisService := service.NewAnalysisService(etherscanClient)
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	chainRegistry, err := chains.Load(cfg.ChainsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := newSourceRegistry(cfg, chainRegistry)
+	if err != nil {
+		return nil, err
+	}
+
+	labelRegistry, err := labels.Load(cfg.LabelsFile, cfg.LabelsImport)
+	if err != nil {
+		return nil, err
+	}
+
+	screener, err := screening.New(cfg.SanctionsLists, cfg.SanctionsCheckInterval)
+	if err != nil {
+		return nil, err
+	}
+	analysisService := service.NewAnalysisService(sources, chainRegistry, cfg.ChainConcurrency)
 
 	return &Handler{
 		analysisService: analysisService,
+		chains:          chainRegistry,
+		labels:          labelRegistry,
+		screener:        screener,
+		jobs:            jobs.NewManager(cfg.JobWorkers, cfg.JobQueueSize, cfg.JobRetention),
+		requestTimeout:  cfg.RequestTimeout,
+		jobTimeout:      cfg.JobTimeout,
+		batch:           batchLimits{concurrency: cfg.BatchConcurrency, maxAddresses: cfg.BatchMaxAddresses},
+	}, nil
+}
+
+// newSourceRegistry registers every transaction source enabled in the configuration,
+// putting the remote ones behind the response cache and the local transaction store.
+// Etherscan requests for chains with their own API URL in the chain registry go there.
+func newSourceRegistry(cfg *config.Config, chainRegistry *chains.Registry) (*client.SourceRegistry, error) {
+	options := client.Options{
+		Timeout:        cfg.EtherscanTimeout,
+		RateLimit:      cfg.EtherscanRateLimit,
+		RateBurst:      cfg.EtherscanRateBurst,
+		MaxRetries:     cfg.EtherscanMaxRetries,
+		RetryBaseDelay: cfg.EtherscanRetryBaseDelay,
+		RetryMaxDelay:  cfg.EtherscanRetryMaxDelay,
+	}
+
+	etherscanOptions := options
+	etherscanOptions.ChainURLs = chainRegistry.APIURLs()
+
+	remote := map[string]client.TransactionSource{
+		"etherscan": client.NewClient(cfg.EtherscanBaseURL, cfg.EtherscanAPIKey, etherscanOptions),
+	}
+	if cfg.BlockscoutBaseURL != "" {
+		remote["blockscout"] = client.NewClient(cfg.BlockscoutBaseURL, cfg.BlockscoutAPIKey, options)
+	}
+
+	var cache *client.ResponseCache
+	if cfg.CacheMaxMB > 0 {
+		var err error
+		cache, err = client.NewResponseCache(client.CacheOptions{
+			MaxBytes:      cfg.CacheMaxMB << 20,
+			Dir:           cfg.CacheDir,
+			TTL:           cfg.CacheTTL,
+			FinalizedTTL:  cfg.CacheFinalizedTTL,
+			FinalityDepth: int64(cfg.CacheFinalityDepth),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var txStore *store.Store
+	if cfg.StoreDir != "" {
+		var err error
+		if txStore, err = store.New(cfg.StoreDir); err != nil {
+			return nil, err
+		}
+	}
+
+	sources := client.NewSourceRegistry(cfg.TxSource)
+	for name, source := range remote {
+		if cache != nil {
+			source = client.NewCachedSource(name, source, cache)
+		}
+		if txStore != nil {
+			source = client.NewSyncedSource(name, source, txStore, int64(cfg.StoreFinalityDepth))
+		}
+		sources.Register(name, source)
+	}
+	if cfg.FixtureDir != "" {
+		sources.Register("fixture", client.NewFixtureSource(cfg.FixtureDir))
 	}
+
+	return sources, nil
 }
 
 // FilterAndSortParams defines the parameters for filtering and sorting transactions
@@ -41,24 +148,43 @@ type FilterAndSortParams struct {
 	Offset     int
 	Sort       string // "asc" or "desc" for the API call
   ApiKey     string
+	Source     string    // Transaction source name, empty for the configured default
+	Exhaustive bool      // Walk every page of the block range
+	From       time.Time // Start of the date range, zero for none
+	To         time.Time // End of the date range, zero for none
+	ChainIds   []int     // Every requested chain, more than one for a cross-chain analysis
+	AllChains  bool      // Analyze every chain in the chain registry
+	Partial    bool      // Analyze the transaction types that could be retrieved if others fail
+
+	// Request handling params
+	Timeout time.Duration // Deadline of the analysis, 0 for the configured default
 
 	// Custom filtering params (applied after fetching data)
-	MinAmount   float64
-	MaxAmount   float64
+	Asset       string // Asset key ("native" or token contract) that amount filters and sorting apply to
+	MinAmount   *big.Rat // nil means no minimum
+	MaxAmount   *big.Rat // nil means no maximum
 	SortBy      string // "amount"
 	Limit       int    // Maximum number of results to return
 	WithZeroTxs bool   // Include entries with zero amount transactions
+
+	// Label filters (applied to the labels of counterparties)
+	Categories        []string // Only keep counterparties with a label in one of these categories
+	ExcludeCategories []string // Drop counterparties with a label in one of these categories
+
+	// Account types (looked up for counterparties when requested)
+	AccountTypes      bool     // Classify counterparties as EOAs, contracts or precompiles
+	CounterpartyTypes []string // Only keep counterparties of one of these account types
+	MaxLookups        int      // Counterparties classified at most, first in the sort order
 }
 
 
-// parseQueryParams extracts and validates the filter and sort parameters from the request
-func parseQueryParams(r *http.Request) (FilterAndSortParams, error) {
-	query := r.URL.Query()
+// parseQueryParams extracts and validates the filter and sort parameters from the query
+func parseQueryParams(query url.Values) (FilterAndSortParams, error) {
 	params := FilterAndSortParams{
 		Address:     query.Get("address"),
     ChainId:     1,          // Default to 1, Ethereum Mainnet
-		MinAmount:   0,
-		MaxAmount:   -1, // Negative value means no maximum limit
+		MinAmount:   nil,
+		MaxAmount:   nil, // No maximum limit
 		StartBlock:  0,
 		EndBlock:    -1,       // Negative value means no end block limit
 		Page:        1,        // Default to first page
@@ -68,24 +194,37 @@ func parseQueryParams(r *http.Request) (FilterAndSortParams, error) {
     ApiKey:       "",
 		Limit:       100,      // Default limit is 100 results
 		WithZeroTxs: true,     // By default, include zero amount transactions
+		MaxLookups:  defaultMaxLookups,
 	}
   
-  // Parse chain in
-  if chainId := query.Get("chainid"); chainId != ""{
-    chainId, err := strconv.Atoi(chainId)
-
-    if err != nil{
-      return params, err
-    }
+	// Parse chain ids, given as repeated or comma-separated values, or "all"
+	for _, value := range query["chainid"] {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if strings.EqualFold(field, "all") {
+				params.AllChains = true
+				continue
+			}
+
+			chainId, err := strconv.Atoi(field)
+			if err != nil {
+				return params, err
+			}
+			params.ChainIds = append(params.ChainIds, chainId)
+		}
+	}
+	if len(params.ChainIds) > 0 {
+		params.ChainId = params.ChainIds[0]
+	}
 
-    if validateChainId(chainId){
-      params.ChainId = chainId
-    }
-  }
+	// Parse asset
+	if asset := query.Get("asset"); asset != "" {
+		params.Asset = strings.ToLower(asset)
+	}
 
 	// Parse min amount
 	if minAmtStr := query.Get("min"); minAmtStr != "" {
-		minAmt, err := strconv.ParseFloat(minAmtStr, 64)
+		minAmt, err := utils.ParseDecimal(minAmtStr)
 		if err != nil {
 			return params, err
 		}
@@ -94,11 +233,14 @@ func parseQueryParams(r *http.Request) (FilterAndSortParams, error) {
 
 	// Parse max amount
 	if maxAmtStr := query.Get("max"); maxAmtStr != "" {
-		maxAmt, err := strconv.ParseFloat(maxAmtStr, 64)
+		maxAmt, err := utils.ParseDecimal(maxAmtStr)
 		if err != nil {
 			return params, err
 		}
-		params.MaxAmount = maxAmt
+		// Zero means no maximum limit
+		if maxAmt.Sign() > 0 {
+			params.MaxAmount = maxAmt
+		}
 	}
 
 	// Parse start block
@@ -119,6 +261,25 @@ func parseQueryParams(r *http.Request) (FilterAndSortParams, error) {
 		params.EndBlock = endBlock
 	}
 
+	// Parse date range
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := parseDate(fromStr, false)
+		if err != nil {
+			return params, err
+		}
+		params.From = from
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := parseDate(toStr, true)
+		if err != nil {
+			return params, err
+		}
+		params.To = to
+	}
+	if !params.From.IsZero() && !params.To.IsZero() && params.From.After(params.To) {
+		return params, errors.New("from must not be after to")
+	}
+
 	// Parse page
 	if pageStr := query.Get("page"); pageStr != "" {
 		page, err := strconv.Atoi(pageStr)
@@ -153,6 +314,11 @@ func parseQueryParams(r *http.Request) (FilterAndSortParams, error) {
     params.ApiKey = apiKey
   }
 
+	// Parse transaction source
+	if source := query.Get("source"); source != "" {
+		params.Source = strings.ToLower(source)
+	}
+
 	// Parse sort order
 	if sortOrder := query.Get("sort"); sortOrder != "" {
 		// Validate sort order parameter
@@ -177,6 +343,36 @@ func parseQueryParams(r *http.Request) (FilterAndSortParams, error) {
 		params.Limit = limit
 	}
 
+	// Parse exhaustive
+	if exhaustiveStr := query.Get("exhaustive"); exhaustiveStr != "" {
+		exhaustive, err := strconv.ParseBool(exhaustiveStr)
+		if err != nil {
+			return params, err
+		}
+		params.Exhaustive = exhaustive
+	}
+
+	// Parse partial
+	if partialStr := query.Get("partial"); partialStr != "" {
+		partial, err := strconv.ParseBool(partialStr)
+		if err != nil {
+			return params, err
+		}
+		params.Partial = partial
+	}
+
+	// Parse timeout
+	if timeoutStr := query.Get("timeout"); timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return params, err
+		}
+		if timeout <= 0 {
+			return params, errors.New("timeout must be positive")
+		}
+		params.Timeout = timeout
+	}
+
 	// Parse with_zero_txs
 	if withZeroTxsStr := query.Get("with_zero_txs"); withZeroTxsStr != "" {
 		withZeroTxs, err := strconv.ParseBool(withZeroTxsStr)
@@ -186,9 +382,61 @@ func parseQueryParams(r *http.Request) (FilterAndSortParams, error) {
 		params.WithZeroTxs = withZeroTxs
 	}
 
+	// Parse label categories
+	var err error
+	if params.Categories, err = parseCategories(query["category"]); err != nil {
+		return params, err
+	}
+	if params.ExcludeCategories, err = parseCategories(query["exclude_category"]); err != nil {
+		return params, err
+	}
+
+	// Parse account types
+	if params.CounterpartyTypes, err = parseAccountTypes(query["counterparty_type"]); err != nil {
+		return params, err
+	}
+	if accountTypesStr := query.Get("account_types"); accountTypesStr != "" {
+		accountTypes, err := strconv.ParseBool(accountTypesStr)
+		if err != nil {
+			return params, err
+		}
+		params.AccountTypes = accountTypes
+	}
+	// Filtering by account type needs them
+	if len(params.CounterpartyTypes) > 0 {
+		params.AccountTypes = true
+	}
+	if maxLookupsStr := query.Get("max_lookups"); maxLookupsStr != "" {
+		maxLookups, err := strconv.Atoi(maxLookupsStr)
+		if err != nil {
+			return params, err
+		}
+		if maxLookups <= 0 || maxLookups > maxMaxLookups {
+			return params, fmt.Errorf("max_lookups must be between 1 and %d", maxMaxLookups)
+		}
+		params.MaxLookups = maxLookups
+	}
+
 	return params, nil
 }
 
+// parseDate parses an RFC 3339 time or a date. A date stands for the start of the day in UTC,
+// or its last second if endOfDay is set, so that date ranges are inclusive.
+func parseDate(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC 3339", value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return t, nil
+}
+
 // validateAddress checks if the provided Ethereum address is valid
 func validateAddress(address string) error {
 	address = strings.ToLower(address)
@@ -198,57 +446,45 @@ func validateAddress(address string) error {
 	return nil
 }
 
-// validateChainId checks if the provided chain id is valid
-func validateChainId(chainid int) bool {
-  validChainIDs := map[int]struct{}{
-    1: {}, 11155111: {}, 17000: {}, 2741: {}, 11124: {},
-    33111: {}, 33139: {}, 42170: {}, 42161: {}, 421614: {},
-    43114: {}, 43113: {}, 8453: {}, 84532: {}, 80094: {},
-    80069: {}, 199: {}, 1028: {}, 81457: {}, 168587773: {},
-    56: {}, 97: {}, 44787: {}, 42220: {}, 25: {},
-    252: {}, 2522: {}, 100: {}, 59144: {}, 59141: {},
-    5000: {}, 5003: {}, 4352: {}, 43521: {}, 1287: {},
-    1284: {}, 1285: {}, 10: {}, 11155420: {}, 80002: {},
-    137: {}, 2442: {}, 1101: {}, 534352: {}, 534351: {},
-    57054: {}, 146: {}, 50104: {}, 531050104: {}, 1923: {},
-    1924: {}, 167009: {}, 167000: {}, 130: {}, 1301: {},
-    1111: {}, 1112: {}, 480: {}, 4801: {}, 660279: {},
-    37714555429: {}, 51: {}, 50: {}, 324: {}, 300: {},
-  }
-  _, ok := validChainIDs[chainid]
-  return ok
-}
-
 // httpHelper contains common HTTP handler operations
 type httpHelper struct{}
 
 // ensureMethod ensures the request uses the allowed HTTP method
 func (h httpHelper) ensureMethod(w http.ResponseWriter, r *http.Request, method string) bool {
 	if r.Method != method {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		h.respondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
 		return false
 	}
 	return true
 }
 
-// getValidParams extracts and validates params and address
-func (h httpHelper) getValidParams(w http.ResponseWriter, r *http.Request) (FilterAndSortParams, bool) {
+// getValidParams extracts and validates params and address, allowing a timeout up to maxTimeout
+func (h httpHelper) getValidParams(w http.ResponseWriter, r *http.Request, maxTimeout time.Duration) (FilterAndSortParams, bool) {
+	return h.getValidQueryParams(w, r.URL.Query(), maxTimeout)
+}
+
+// getValidQueryParams extracts and validates params and address from query, allowing a timeout
+// up to maxTimeout
+func (h httpHelper) getValidQueryParams(w http.ResponseWriter, query url.Values, maxTimeout time.Duration) (FilterAndSortParams, bool) {
 	// Parse filter and sort parameters
-	params, err := parseQueryParams(r)
+	params, err := parseQueryParams(query)
+	if err == nil {
+		err = checkTimeout(params, maxTimeout)
+	}
 	if err != nil {
-		http.Error(w, "Invalid query parameters: "+err.Error(), http.StatusBadRequest)
+		h.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid query parameters: "+err.Error())
 		return params, false
 	}
 
 	// Check address presence
 	if params.Address == "" {
-		http.Error(w, "Address parameter is required", http.StatusBadRequest)
+		h.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Address parameter is required")
 		return params, false
 	}
 
 	// Validate the Ethereum address
 	if err := validateAddress(params.Address); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.respondWithError(w, http.StatusBadRequest, "invalid_address", err.Error())
 		return params, false
 	}
 
@@ -266,6 +502,108 @@ func (h httpHelper) toAnalysisParams(params FilterAndSortParams) service.Analysi
 		Offset:     params.Offset,
 		Sort:       params.Sort,
     ApiKey:     params.ApiKey,
+		Source:     params.Source,
+		Exhaustive: params.Exhaustive,
+		From:       params.From,
+		To:         params.To,
+		ChainIds:   params.ChainIds,
+		AllChains:  params.AllChains,
+		Partial:    params.Partial,
+	}
+}
+
+// statusClientClosedRequest reports that the client went away before the response was ready
+const statusClientClosedRequest = 499
+
+// analysisErrors maps error kinds from the service and client to HTTP status codes
+// and error codes, in order of precedence
+var analysisErrors = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, "timeout"},
+	{context.Canceled, statusClientClosedRequest, "cancelled"},
+	{client.ErrUnknownSource, http.StatusBadRequest, "unknown_source"},
+	{errors.ErrUnsupported, http.StatusBadRequest, "unsupported"},
+	{client.ErrInvalidChain, http.StatusBadRequest, "invalid_chain"},
+	{client.ErrInvalidRequest, http.StatusBadRequest, "invalid_request"},
+	{client.ErrInvalidAPIKey, http.StatusUnauthorized, "invalid_api_key"},
+	{client.ErrRateLimited, http.StatusTooManyRequests, "rate_limited"},
+	{client.ErrUpstream, http.StatusBadGateway, "upstream_unavailable"},
+	{client.ErrNoTransactions, http.StatusNotFound, "no_transactions"},
+}
+
+// checkTimeout rejects a requested timeout longer than maxTimeout, the configured default
+// deadline, unless that is 0 for none
+func checkTimeout(params FilterAndSortParams, maxTimeout time.Duration) error {
+	if maxTimeout > 0 && params.Timeout > maxTimeout {
+		return fmt.Errorf("timeout must not exceed %s", maxTimeout)
+	}
+	return nil
+}
+
+// requestContext returns the context of an analysis: the request's context, which is
+// cancelled when the client disconnects, bounded by the requested or default timeout
+func (h *Handler) requestContext(r *http.Request, params FilterAndSortParams) (context.Context, context.CancelFunc) {
+	timeout := params.Timeout
+	if timeout == 0 {
+		timeout = h.requestTimeout
+	}
+	if timeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+// classifyAnalysisError returns the status code, error code and detail of a failed
+// analysis according to its error kind, falling back to an internal server error
+func classifyAnalysisError(err error, msg string) (int, models.ErrorDetail) {
+	for _, e := range analysisErrors {
+		if !errors.Is(err, e.kind) {
+			continue
+		}
+
+		detail := e.kind.Error()
+		var apiErr *client.APIError
+		if errors.As(err, &apiErr) && apiErr.Kind == e.kind {
+			detail = apiErr.Error()
+		} else if e.kind == client.ErrUnknownSource || e.kind == client.ErrNoTransactions || e.kind == errors.ErrUnsupported ||
+			e.kind == client.ErrInvalidChain {
+			detail = err.Error()
+		}
+
+		return e.status, models.ErrorDetail{Code: e.code, Detail: msg + ": " + detail}
+	}
+
+	return http.StatusInternalServerError, models.ErrorDetail{Code: "internal_error", Detail: msg}
+}
+
+// respondWithAnalysisError reports a failed analysis with the status code of its error kind
+func (h httpHelper) respondWithAnalysisError(w http.ResponseWriter, err error, msg string) {
+	log.Printf("%s: %v", msg, err)
+
+	status, detail := classifyAnalysisError(err, msg)
+	if status == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", "1")
+	}
+	h.respondWithError(w, status, detail.Code, detail.Detail)
+}
+
+// respondWithError sends a JSON error response
+func (h httpHelper) respondWithError(w http.ResponseWriter, status int, code, detail string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	response := models.ErrorResponse{
+		Message: "error",
+		Error: models.ErrorDetail{
+			Code:   code,
+			Detail: detail,
+		},
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding error response: %v", err)
 	}
 }
 
@@ -278,6 +616,15 @@ func (h httpHelper) respondWithJSON(w http.ResponseWriter, data interface{}) {
 	}
 }
 
+// respondWithJSONStatus sends a JSON response with the given status code
+func (h httpHelper) respondWithJSONStatus(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
 // BeneficiaryHandler handles requests to the /beneficiary endpoint
 func (h *Handler) BeneficiaryHandler(w http.ResponseWriter, r *http.Request) {
 	helper := httpHelper{}
@@ -288,32 +635,53 @@ func (h *Handler) BeneficiaryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse and validate parameters
-	params, ok := helper.getValidParams(w, r)
+	params, ok := helper.getValidParams(w, r, h.requestTimeout)
 	if !ok {
 		return
 	}
 
-	// Get beneficiaries from the service
-	beneficiaries, err := h.analysisService.AnalyzeBeneficiaries(helper.toAnalysisParams(params))
-	if err != nil {
-		log.Printf("Error analyzing beneficiaries: %v", err)
-		http.Error(w, "Failed to analyze beneficiaries", http.StatusInternalServerError)
+	format, ok := helper.getGraphFormat(w, r)
+	if !ok {
 		return
 	}
 
-	// Apply filtering and sorting
-	filteredBeneficiaries := filterBeneficiaries(beneficiaries, params)
+	ctx, cancel := h.requestContext(r, params)
+	defer cancel()
 
-	// Create the response
-	response := models.BeneficiaryResponse{
-		Message: "success",
-		Data:    filteredBeneficiaries,
+	// Get the filtered beneficiaries
+	response, err := h.beneficiaries(ctx, params)
+	if err != nil {
+		helper.respondWithAnalysisError(w, err, "Failed to analyze beneficiaries")
+		return
+	}
+
+	// Render the graph export if requested
+	if format != "" {
+		helper.respondWithGraph(w, format, export.FromBeneficiaries(params.Address, response.Data))
+		return
 	}
 
 	// Send JSON response
 	helper.respondWithJSON(w, response)
 }
 
+// beneficiaries runs the beneficiary analysis and creates the /beneficiary response
+func (h *Handler) beneficiaries(ctx context.Context, params FilterAndSortParams) (models.BeneficiaryResponse, error) {
+	// Get labelled beneficiaries from the service
+	beneficiaries, status, err := h.analyzeBeneficiaries(ctx, params)
+	if err != nil {
+		return models.BeneficiaryResponse{}, err
+	}
+
+	// Apply filtering and sorting, screening every beneficiary
+	return models.BeneficiaryResponse{
+		Message:     "success",
+		FetchStatus: status,
+		Data:        filterBeneficiaries(beneficiaries, params),
+		Screening:   h.screener.Screen(params.Address, beneficiaryExposures(beneficiaries)),
+	}, nil
+}
+
 // PayerHandler handles requests to the /payer endpoint
 func (h *Handler) PayerHandler(w http.ResponseWriter, r *http.Request) {
 	helper := httpHelper{}
@@ -324,48 +692,61 @@ func (h *Handler) PayerHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse and validate parameters
-	params, ok := helper.getValidParams(w, r)
+	params, ok := helper.getValidParams(w, r, h.requestTimeout)
 	if !ok {
 		return
 	}
 
-	// Get payers from the service
-	payers, err := h.analysisService.AnalyzePayers(helper.toAnalysisParams(params))
-	if err != nil {
-		log.Printf("Error analyzing payers: %v", err)
-		http.Error(w, "Failed to analyze payers", http.StatusInternalServerError)
+	format, ok := helper.getGraphFormat(w, r)
+	if !ok {
 		return
 	}
 
-	// Apply filtering and sorting
-	filteredPayers := filterPayers(payers, params)
+	ctx, cancel := h.requestContext(r, params)
+	defer cancel()
 
-	// Create the response
-	response := models.PayerResponse{
-		Message: "success",
-		Data:    filteredPayers,
+	// Get the filtered payers
+	response, err := h.payers(ctx, params)
+	if err != nil {
+		helper.respondWithAnalysisError(w, err, "Failed to analyze payers")
+		return
+	}
+
+	// Render the graph export if requested
+	if format != "" {
+		helper.respondWithGraph(w, format, export.FromPayers(params.Address, response.Data))
+		return
 	}
 
 	// Send JSON response
 	helper.respondWithJSON(w, response)
 }
 
+// payers runs the payer analysis and creates the /payer response
+func (h *Handler) payers(ctx context.Context, params FilterAndSortParams) (models.PayerResponse, error) {
+	// Get labelled payers from the service
+	payers, status, err := h.analyzePayers(ctx, params)
+	if err != nil {
+		return models.PayerResponse{}, err
+	}
+
+	// Apply filtering and sorting, screening every payer
+	return models.PayerResponse{
+		Message:     "success",
+		FetchStatus: status,
+		Data:        filterPayers(payers, params),
+		Screening:   h.screener.Screen(params.Address, payerExposures(payers)),
+	}, nil
+}
+
 // filterBeneficiaries applies filtering and sorting to beneficiaries based on the params
 func filterBeneficiaries(beneficiaries []models.Beneficiary, params FilterAndSortParams) []models.Beneficiary {
 	filtered := []models.Beneficiary{}
 
 	// Apply filters
 	for _, ben := range beneficiaries {
-		// Skip beneficiaries with zero amount if not explicitly requested
-		if ben.Amount == 0 && !params.WithZeroTxs {
-			continue
-		}
-
-		// Apply amount filters
-		if ben.Amount < params.MinAmount {
-			continue
-		}
-		if params.MaxAmount > 0 && ben.Amount > params.MaxAmount {
+		if !matchesAmountFilters(ben.Amount, ben.Assets, params) || !matchesLabelFilters(ben.Labels, params) ||
+			!matchesAccountTypeFilter(ben.AccountType, params) {
 			continue
 		}
 
@@ -376,11 +757,11 @@ func filterBeneficiaries(beneficiaries []models.Beneficiary, params FilterAndSor
 	if params.SortBy == "amount" {
 		if params.Sort == "asc" {
 			sort.Slice(filtered, func(i, j int) bool {
-				return filtered[i].Amount < filtered[j].Amount
+				return compareSortAmounts(filtered[i].Amount, filtered[i].Assets, filtered[j].Amount, filtered[j].Assets, params) < 0
 			})
 		} else {
 			sort.Slice(filtered, func(i, j int) bool {
-				return filtered[i].Amount > filtered[j].Amount
+				return compareSortAmounts(filtered[i].Amount, filtered[i].Assets, filtered[j].Amount, filtered[j].Assets, params) > 0
 			})
 		}
 	}
@@ -399,16 +780,8 @@ func filterPayers(payers []models.Payer, params FilterAndSortParams) []models.Pa
 
 	// Apply filters
 	for _, payer := range payers {
-		// Skip payers with zero amount if not explicitly requested
-		if payer.Amount == 0 && !params.WithZeroTxs {
-			continue
-		}
-
-		// Apply amount filters
-		if payer.Amount < params.MinAmount {
-			continue
-		}
-		if params.MaxAmount > 0 && payer.Amount > params.MaxAmount {
+		if !matchesAmountFilters(payer.Amount, payer.Assets, params) || !matchesLabelFilters(payer.Labels, params) ||
+			!matchesAccountTypeFilter(payer.AccountType, params) {
 			continue
 		}
 
@@ -419,11 +792,11 @@ func filterPayers(payers []models.Payer, params FilterAndSortParams) []models.Pa
 	if params.SortBy == "amount" {
 		if params.Sort == "asc" {
 			sort.Slice(filtered, func(i, j int) bool {
-				return filtered[i].Amount < filtered[j].Amount
+				return compareSortAmounts(filtered[i].Amount, filtered[i].Assets, filtered[j].Amount, filtered[j].Assets, params) < 0
 			})
 		} else {
 			sort.Slice(filtered, func(i, j int) bool {
-				return filtered[i].Amount > filtered[j].Amount
+				return compareSortAmounts(filtered[i].Amount, filtered[i].Assets, filtered[j].Amount, filtered[j].Assets, params) > 0
 			})
 		}
 	}
@@ -435,3 +808,47 @@ func filterPayers(payers []models.Payer, params FilterAndSortParams) []models.Pa
 
 	return filtered
 }
+
+// matchesAmountFilters reports whether a counterparty passes the zero-amount, min and max filters.
+// With an asset selected only that asset's amount is checked; otherwise it is enough
+// for any one asset to pass, so token-only counterparties are not dropped.
+func matchesAmountFilters(nativeAmount utils.Amount, assets map[string]*models.AssetAmount, params FilterAndSortParams) bool {
+	var amounts []utils.Amount
+	if params.Asset != "" {
+		amounts = append(amounts, models.AssetAmountOf(nativeAmount, assets, params.Asset))
+	} else {
+		amounts = append(amounts, nativeAmount)
+		for key, a := range assets {
+			if key != models.NativeAsset {
+				amounts = append(amounts, a.Amount)
+			}
+		}
+	}
+
+	for _, amount := range amounts {
+		// Skip zero amounts if not explicitly requested
+		if amount.IsZero() && !params.WithZeroTxs {
+			continue
+		}
+
+		// Apply amount filters
+		if params.MinAmount != nil && amount.Cmp(params.MinAmount) < 0 {
+			continue
+		}
+		if params.MaxAmount != nil && amount.Cmp(params.MaxAmount) > 0 {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// compareSortAmounts compares two counterparties by the selected asset's amount,
+// or by their native amount if no asset is selected
+func compareSortAmounts(nativeA utils.Amount, assetsA map[string]*models.AssetAmount, nativeB utils.Amount, assetsB map[string]*models.AssetAmount, params FilterAndSortParams) int {
+	a := models.AssetAmountOf(nativeA, assetsA, params.Asset)
+	b := models.AssetAmountOf(nativeB, assetsB, params.Asset)
+	return a.CmpAmount(b)
+}
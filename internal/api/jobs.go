@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/jobs"
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// jobAnalysis is an analysis that can run as a background job
+type jobAnalysis struct {
+	failure string // Message of a failed job, as in the endpoint's error responses
+	prepare func(h *Handler, query url.Values, params FilterAndSortParams) (jobs.Func, error)
+}
+
+// jobAnalyses are the analyses accepted by POST /jobs, named after their endpoints.
+// A job's result is the response its endpoint would send.
+var jobAnalyses = map[string]jobAnalysis{
+	"beneficiary": {"Failed to analyze beneficiaries", func(h *Handler, query url.Values, params FilterAndSortParams) (jobs.Func, error) {
+		return func(ctx context.Context) (any, error) { return h.beneficiaries(ctx, params) }, nil
+	}},
+	"payer": {"Failed to analyze payers", func(h *Handler, query url.Values, params FilterAndSortParams) (jobs.Func, error) {
+		return func(ctx context.Context) (any, error) { return h.payers(ctx, params) }, nil
+	}},
+	"counterparties": {"Failed to analyze counterparties", func(h *Handler, query url.Values, params FilterAndSortParams) (jobs.Func, error) {
+		if err := validateFlowMeasure(params); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) (any, error) { return h.counterparties(ctx, params) }, nil
+	}},
+	"timeseries": {"Failed to analyze time series", func(h *Handler, query url.Values, params FilterAndSortParams) (jobs.Func, error) {
+		seriesParams, err := parseTimeSeriesParams(query, params)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) (any, error) { return h.timeSeries(ctx, seriesParams) }, nil
+	}},
+	"trace": {"Failed to trace funds", func(h *Handler, query url.Values, params FilterAndSortParams) (jobs.Func, error) {
+		traceParams, err := parseTraceParams(query, params)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) (any, error) { return h.trace(ctx, traceParams) }, nil
+	}},
+	"risk": {"Failed to score risk", func(h *Handler, query url.Values, params FilterAndSortParams) (jobs.Func, error) {
+		riskParams, err := h.parseRiskParams(query, params)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) (any, error) { return h.risk(ctx, riskParams) }, nil
+	}},
+}
+
+// parseBodyQuery collects the parameters of a job or batch from the query string and the request body,
+// which is either a form or a JSON object of parameter values. Body values take precedence.
+func parseBodyQuery(r *http.Request) (url.Values, error) {
+	query := r.URL.Query()
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		var body map[string]any
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("invalid JSON body: %w", err)
+		}
+
+		for key, value := range body {
+			values, err := jsonQueryValues(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			query[key] = values
+		}
+	case "", "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		for key, values := range r.PostForm {
+			query[key] = values
+		}
+	default:
+		return nil, fmt.Errorf("unsupported content type %q, expected application/json or a form", mediaType)
+	}
+
+	return query, nil
+}
+
+// jsonQueryValues converts a JSON value into query parameter values.
+// Arrays stand for repeated parameters.
+func jsonQueryValues(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case json.Number:
+		return []string{v.String()}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case []any:
+		var values []string
+		for _, item := range v {
+			itemValues, err := jsonQueryValues(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, itemValues...)
+		}
+		return values, nil
+	default:
+		return nil, errors.New("expected a string, number, boolean or array")
+	}
+}
+
+// toJobModel converts a job snapshot into its response representation
+func toJobModel(info jobs.Info) models.Job {
+	job := models.Job{
+		ID:        info.ID,
+		Analysis:  info.Analysis,
+		Status:    info.Status,
+		CreatedAt: info.CreatedAt,
+		Progress:  info.Progress,
+		Result:    info.Result,
+	}
+	if !info.StartedAt.IsZero() {
+		job.StartedAt = &info.StartedAt
+	}
+	if info.Finished() {
+		job.FinishedAt = &info.FinishedAt
+	}
+	if info.Err != nil {
+		_, detail := classifyAnalysisError(info.Err, jobAnalyses[info.Analysis].failure)
+		job.Error = &detail
+	}
+	return job
+}
+
+// JobsHandler handles requests to the /jobs endpoint, which submits an analysis as a background job
+func (h *Handler) JobsHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	// Validate HTTP method
+	if !helper.ensureMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	query, err := parseBodyQuery(r)
+	if err != nil {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid job parameters: "+err.Error())
+		return
+	}
+
+	// Look up the analysis
+	name := strings.ToLower(query.Get("analysis"))
+	analysis, ok := jobAnalyses[name]
+	if !ok {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters",
+			fmt.Sprintf("Invalid job parameters: invalid analysis %q, expected beneficiary, payer, counterparties, timeseries, trace or risk", name))
+		return
+	}
+	if format := strings.ToLower(query.Get("format")); format != "" && format != "json" {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid job parameters: jobs only return JSON results")
+		return
+	}
+
+	// Parse and validate parameters
+	params, ok := helper.getValidQueryParams(w, query, h.jobTimeout)
+	if !ok {
+		return
+	}
+
+	run, err := analysis.prepare(h, query, params)
+	if err != nil {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	timeout := params.Timeout
+	if timeout == 0 {
+		timeout = h.jobTimeout
+	}
+
+	// Queue the job
+	info, err := h.jobs.Submit(name, timeout, run)
+	if errors.Is(err, jobs.ErrQueueFull) {
+		w.Header().Set("Retry-After", "30")
+		helper.respondWithError(w, http.StatusServiceUnavailable, "queue_full", "Failed to submit job: "+err.Error())
+		return
+	}
+	if err != nil {
+		helper.respondWithAnalysisError(w, err, "Failed to submit job")
+		return
+	}
+
+	w.Header().Set("Location", "/jobs/"+info.ID)
+	helper.respondWithJSONStatus(w, http.StatusAccepted, models.JobResponse{
+		Message: "success",
+		Data:    toJobModel(info),
+	})
+}
+
+// JobHandler handles requests to the /jobs/{id} endpoint, reporting a job on GET and cancelling it on DELETE
+func (h *Handler) JobHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	var info jobs.Info
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		info, err = h.jobs.Get(r.PathValue("id"))
+	case http.MethodDelete:
+		info, err = h.jobs.Cancel(r.PathValue("id"))
+	default:
+		helper.respondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		return
+	}
+	if err != nil {
+		helper.respondWithError(w, http.StatusNotFound, "job_not_found", err.Error())
+		return
+	}
+
+	// Send JSON response
+	helper.respondWithJSON(w, models.JobResponse{
+		Message: "success",
+		Data:    toJobModel(info),
+	})
+}
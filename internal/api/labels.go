@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/labels"
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// parseCategories parses label categories, given as repeated or comma-separated values
+func parseCategories(values []string) ([]string, error) {
+	var categories []string
+	for _, value := range values {
+		for _, category := range strings.Split(value, ",") {
+			category = strings.ToLower(strings.TrimSpace(category))
+			if category == "" {
+				continue
+			}
+			if !slices.Contains(labels.Categories, category) {
+				return nil, fmt.Errorf("invalid category %q, expected one of %s", category, strings.Join(labels.Categories, ", "))
+			}
+			categories = append(categories, category)
+		}
+	}
+	return categories, nil
+}
+
+// matchesLabelFilters reports whether a counterparty with the given labels passes the
+// category and exclude_category filters
+func matchesLabelFilters(labelList []models.Label, params FilterAndSortParams) bool {
+	included := len(params.Categories) == 0
+	for _, label := range labelList {
+		if slices.Contains(params.ExcludeCategories, label.Category) {
+			return false
+		}
+		if slices.Contains(params.Categories, label.Category) {
+			included = true
+		}
+	}
+	return included
+}
+
+// analyzeBeneficiaries runs the beneficiary analysis, labels the beneficiaries and
+// classifies their accounts if requested
+func (h *Handler) analyzeBeneficiaries(ctx context.Context, params FilterAndSortParams) ([]models.Beneficiary, models.FetchStatus, error) {
+	beneficiaries, status, err := h.analysisService.AnalyzeBeneficiaries(ctx, httpHelper{}.toAnalysisParams(params))
+	if err != nil {
+		return nil, status, err
+	}
+
+	for i := range beneficiaries {
+		beneficiaries[i].Labels = h.labels.Lookup(beneficiaries[i].Address)
+	}
+
+	// Classify the beneficiaries that can pass the filters
+	var counterparties []accountCounterparty
+	if params.AccountTypes {
+		for _, ben := range filterBeneficiaries(beneficiaries, untyped(unlimited(params))) {
+			counterparties = append(counterparties, accountCounterparty{ben.Address, ben.Chains, ben.Created})
+		}
+	}
+
+	types, err := h.accountTypes(ctx, params, counterparties, &status)
+	if err != nil {
+		return nil, status, err
+	}
+	for i := range beneficiaries {
+		beneficiaries[i].AccountType = types[strings.ToLower(beneficiaries[i].Address)]
+	}
+	return beneficiaries, status, nil
+}
+
+// analyzePayers runs the payer analysis, labels the payers and classifies their accounts if requested
+func (h *Handler) analyzePayers(ctx context.Context, params FilterAndSortParams) ([]models.Payer, models.FetchStatus, error) {
+	payers, status, err := h.analysisService.AnalyzePayers(ctx, httpHelper{}.toAnalysisParams(params))
+	if err != nil {
+		return nil, status, err
+	}
+
+	for i := range payers {
+		payers[i].Labels = h.labels.Lookup(payers[i].Address)
+	}
+
+	// Classify the payers that can pass the filters
+	var counterparties []accountCounterparty
+	if params.AccountTypes {
+		for _, payer := range filterPayers(payers, untyped(unlimited(params))) {
+			counterparties = append(counterparties, accountCounterparty{payer.Address, payer.Chains, false})
+		}
+	}
+
+	types, err := h.accountTypes(ctx, params, counterparties, &status)
+	if err != nil {
+		return nil, status, err
+	}
+	for i := range payers {
+		payers[i].AccountType = types[strings.ToLower(payers[i].Address)]
+	}
+	return payers, status, nil
+}
+
+// readLabels reads the labels in a request body, either JSON (a label or an array of them)
+// or CSV with a header row
+func readLabels(r *http.Request) ([]models.Label, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "", "application/json":
+		return labels.ReadJSON(r.Body, "")
+	case "text/csv":
+		return labels.ReadCSV(r.Body, "")
+	default:
+		return nil, fmt.Errorf("unsupported content type %q, expected application/json or text/csv", mediaType)
+	}
+}
+
+// respondWithLabelError sends the error response of a failed label change
+func (h httpHelper) respondWithLabelError(w http.ResponseWriter, err error) {
+	if errors.Is(err, labels.ErrInvalidLabel) {
+		h.respondWithError(w, http.StatusBadRequest, "invalid_label", err.Error())
+		return
+	}
+	h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Failed to store labels: "+err.Error())
+}
+
+// LabelsHandler handles requests to the /labels endpoint, listing labels on GET
+// and adding or replacing them on POST
+func (h *Handler) LabelsHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		if category := query.Get("category"); category != "" {
+			if _, err := parseCategories([]string{category}); err != nil {
+				helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid query parameters: "+err.Error())
+				return
+			}
+		}
+
+		// Send JSON response
+		helper.respondWithJSON(w, models.LabelsResponse{
+			Message: "success",
+			Data:    h.labels.List(query.Get("category"), query.Get("source")),
+		})
+	case http.MethodPost:
+		labelList, err := readLabels(r)
+		if err != nil {
+			helper.respondWithError(w, http.StatusBadRequest, "invalid_label", "Invalid labels: "+err.Error())
+			return
+		}
+
+		stored, err := h.labels.Put(labelList...)
+		if err != nil {
+			helper.respondWithLabelError(w, err)
+			return
+		}
+
+		// Send JSON response
+		helper.respondWithJSON(w, models.LabelsResponse{Message: "success", Data: stored})
+	default:
+		helper.respondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	}
+}
+
+// LabelHandler handles requests to the /labels/{address} endpoint, returning the labels of
+// an address on GET, adding or replacing them on PUT and removing them on DELETE
+func (h *Handler) LabelHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	// Validate the Ethereum address
+	address := r.PathValue("address")
+	if err := validateAddress(address); err != nil {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_address", err.Error())
+		return
+	}
+
+	var labelList []models.Label
+	switch r.Method {
+	case http.MethodGet:
+		labelList = h.labels.Lookup(address)
+	case http.MethodPut:
+		body, err := readLabels(r)
+		if err != nil {
+			helper.respondWithError(w, http.StatusBadRequest, "invalid_label", "Invalid labels: "+err.Error())
+			return
+		}
+
+		// Labels are for the address in the path
+		for i := range body {
+			if body[i].Address != "" && !strings.EqualFold(body[i].Address, address) {
+				helper.respondWithError(w, http.StatusBadRequest, "invalid_label",
+					fmt.Sprintf("Invalid labels: address %s does not match %s", body[i].Address, address))
+				return
+			}
+			body[i].Address = address
+		}
+
+		if labelList, err = h.labels.Put(body...); err != nil {
+			helper.respondWithLabelError(w, err)
+			return
+		}
+	case http.MethodDelete:
+		var err error
+		if labelList, err = h.labels.Delete(address, r.URL.Query().Get("source")); err != nil {
+			helper.respondWithLabelError(w, err)
+			return
+		}
+	default:
+		helper.respondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		return
+	}
+
+	if len(labelList) == 0 {
+		helper.respondWithError(w, http.StatusNotFound, "label_not_found", "No labels for "+address)
+		return
+	}
+
+	// Send JSON response
+	helper.respondWithJSON(w, models.LabelsResponse{Message: "success", Data: labelList})
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// Ledger export formats and their media types
+const (
+	ledgerFormatCSV    = "csv"
+	ledgerFormatNDJSON = "ndjson"
+)
+
+var ledgerContentTypes = map[string]string{
+	ledgerFormatCSV:    "text/csv",
+	ledgerFormatNDJSON: "application/x-ndjson",
+}
+
+// ledgerColumns is the CSV header, in the order of ledgerRecord
+var ledgerColumns = []string{
+	"type", "hash", "block", "timestamp", "date_time", "direction", "from", "to", "counterparty",
+	"asset", "symbol", "token_id", "value", "decimals", "gas_used", "gas_price", "status", "kind",
+}
+
+// Trailers announcing the outcome of a ledger stream, which may fail after the status line was sent
+const (
+	trailerComplete  = "X-Fetch-Complete"
+	trailerTruncated = "X-Fetch-Truncated"
+	trailerError     = "X-Fetch-Error"
+	trailerWarnings  = "X-Fetch-Warnings"
+)
+
+// parseLedgerFormat returns the ledger format requested by the format query
+// parameter or, failing that, the Accept header, defaulting to CSV
+func parseLedgerFormat(r *http.Request) (string, error) {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		if _, ok := ledgerContentTypes[format]; !ok {
+			return "", fmt.Errorf("invalid format %q, expected %s or %s", format, ledgerFormatCSV, ledgerFormatNDJSON)
+		}
+		return format, nil
+	}
+
+	// Negotiate from the Accept header, in the client's order
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		for format, contentType := range ledgerContentTypes {
+			if contentType == mediaType {
+				return format, nil
+			}
+		}
+	}
+
+	return ledgerFormatCSV, nil
+}
+
+// ledgerWriter writes ledger entries in one of the ledger formats
+type ledgerWriter interface {
+	write(entries []models.LedgerEntry) error
+}
+
+// csvLedgerWriter writes ledger entries as CSV rows, starting with a header
+type csvLedgerWriter struct {
+	w *csv.Writer
+}
+
+func (lw *csvLedgerWriter) write(entries []models.LedgerEntry) error {
+	for _, entry := range entries {
+		if err := lw.w.Write(ledgerRecord(entry)); err != nil {
+			return err
+		}
+	}
+	lw.w.Flush()
+	return lw.w.Error()
+}
+
+// ndjsonLedgerWriter writes ledger entries as newline-delimited JSON
+type ndjsonLedgerWriter struct {
+	encoder *json.Encoder
+}
+
+func (lw *ndjsonLedgerWriter) write(entries []models.LedgerEntry) error {
+	for _, entry := range entries {
+		if err := lw.encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newLedgerWriter creates the writer of a ledger format, writing the CSV header right away
+func newLedgerWriter(w io.Writer, format string) (ledgerWriter, error) {
+	if format == ledgerFormatNDJSON {
+		return &ndjsonLedgerWriter{encoder: json.NewEncoder(w)}, nil
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ledgerColumns); err != nil {
+		return nil, err
+	}
+	cw.Flush()
+	return &csvLedgerWriter{w: cw}, cw.Error()
+}
+
+// ledgerRecord converts a ledger entry to a CSV record
+func ledgerRecord(entry models.LedgerEntry) []string {
+	return []string{
+		entry.Type,
+		entry.Hash,
+		strconv.FormatInt(entry.Block, 10),
+		strconv.FormatInt(entry.Timestamp, 10),
+		entry.DateTime,
+		entry.Direction,
+		entry.From,
+		entry.To,
+		entry.Counterparty,
+		entry.Asset,
+		entry.Symbol,
+		entry.TokenID,
+		entry.Value,
+		strconv.Itoa(int(entry.Decimals)),
+		strconv.Itoa(entry.GasUsed),
+		entry.GasPrice,
+		entry.Status,
+		entry.Kind,
+	}
+}
+
+// LedgerHandler handles requests to the /ledger endpoint, streaming every movement
+// of value touching the address as it is fetched
+func (h *Handler) LedgerHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	// Validate HTTP method
+	if !helper.ensureMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	// Parse and validate parameters
+	params, ok := helper.getValidParams(w, r, h.requestTimeout)
+	if !ok {
+		return
+	}
+
+	format, err := parseLedgerFormat(r)
+	if err == nil {
+		err = rejectAccountFilters(params)
+	}
+	if err != nil {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	ctx, cancel := h.requestContext(r, params)
+	defer cancel()
+
+	// The response starts with the first batch, so that failures before it get a proper error response
+	var writer ledgerWriter
+	flusher, _ := w.(http.Flusher)
+	emit := func(entries []models.LedgerEntry) error {
+		if writer == nil {
+			w.Header().Set("Content-Type", ledgerContentTypes[format])
+			w.Header().Set("Trailer", strings.Join([]string{trailerComplete, trailerTruncated, trailerWarnings, trailerError}, ", "))
+			w.WriteHeader(http.StatusOK)
+
+			var err error
+			if writer, err = newLedgerWriter(w, format); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.write(entries); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	status, err := h.analysisService.StreamLedger(ctx, helper.toAnalysisParams(params), emit)
+	if err != nil && writer == nil {
+		helper.respondWithAnalysisError(w, err, "Failed to stream ledger")
+		return
+	}
+
+	// An address without any entries still gets a CSV header or an empty body
+	if writer == nil {
+		if err := emit(nil); err != nil {
+			log.Printf("Error writing ledger: %v", err)
+			return
+		}
+	}
+
+	w.Header().Set(trailerComplete, strconv.FormatBool(status.Complete && err == nil))
+	w.Header().Set(trailerTruncated, strings.Join(status.Truncated, ", "))
+	w.Header().Set(trailerWarnings, formatWarnings(status.Warnings))
+	if err != nil {
+		log.Printf("Failed to stream ledger: %v", err)
+		w.Header().Set(trailerError, err.Error())
+	}
+}
+
+// formatWarnings renders the warnings of a partial ledger as "<type>: <error>; ..."
+func formatWarnings(warnings []models.Warning) string {
+	parts := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		parts = append(parts, warning.TransactionType+": "+warning.Error)
+	}
+	return strings.Join(parts, "; ")
+}
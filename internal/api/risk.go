@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/services"
+	"Ethereum-fund-flow-analysis/internal/utils"
+)
+
+const (
+	defaultFreshContractAge  = 24 * time.Hour
+	defaultPassThroughWindow = time.Hour
+	defaultMaxContracts      = 25
+	maxMaxContracts          = 100
+)
+
+// defaultRiskyCategories are the label categories counted as risky exposure by default
+var defaultRiskyCategories = []string{"mixer", "scam", "sanctioned"}
+
+// defaultRoundMin is the smallest native amount of a round-number transfer by default
+var defaultRoundMin = big.NewRat(10, 1)
+
+// parseRiskParams extracts the risk scoring parameters from the query
+func (h *Handler) parseRiskParams(query url.Values, params FilterAndSortParams) (service.RiskParams, error) {
+	if err := rejectAccountFilters(params); err != nil {
+		return service.RiskParams{}, err
+	}
+
+	riskParams := service.RiskParams{
+		AnalysisParams:    httpHelper{}.toAnalysisParams(params),
+		FreshContractAge:  defaultFreshContractAge,
+		PassThroughWindow: defaultPassThroughWindow,
+		RoundMin:          defaultRoundMin,
+		MaxContracts:      defaultMaxContracts,
+	}
+
+	// Parse risky categories
+	riskyCategories := defaultRiskyCategories
+	if values, ok := query["risky_category"]; ok {
+		categories, err := parseCategories(values)
+		if err != nil {
+			return riskParams, err
+		}
+		riskyCategories = categories
+	}
+	riskParams.Risky = h.riskReasons(riskyCategories)
+
+	// Parse fresh contract age
+	if freshAgeStr := query.Get("fresh_age"); freshAgeStr != "" {
+		freshAge, err := time.ParseDuration(freshAgeStr)
+		if err != nil {
+			return riskParams, err
+		}
+		if freshAge <= 0 {
+			return riskParams, errors.New("fresh_age must be positive")
+		}
+		riskParams.FreshContractAge = freshAge
+	}
+
+	// Parse pass-through window
+	if windowStr := query.Get("window"); windowStr != "" {
+		window, err := time.ParseDuration(windowStr)
+		if err != nil {
+			return riskParams, err
+		}
+		if window <= 0 {
+			return riskParams, errors.New("window must be positive")
+		}
+		riskParams.PassThroughWindow = window
+	}
+
+	// Parse minimum round-number amount
+	if roundMinStr := query.Get("round_min"); roundMinStr != "" {
+		roundMin, err := utils.ParseDecimal(roundMinStr)
+		if err != nil {
+			return riskParams, err
+		}
+		riskParams.RoundMin = roundMin
+	}
+
+	// Parse contract lookup cap
+	if maxContractsStr := query.Get("max_contracts"); maxContractsStr != "" {
+		maxContracts, err := strconv.Atoi(maxContractsStr)
+		if err != nil {
+			return riskParams, err
+		}
+		if maxContracts <= 0 || maxContracts > maxMaxContracts {
+			return riskParams, fmt.Errorf("max_contracts must be between 1 and %d", maxMaxContracts)
+		}
+		riskParams.MaxContracts = maxContracts
+	}
+
+	return riskParams, nil
+}
+
+// riskReasons explains why a counterparty is risky: its labels in one of categories
+// and the sanctions lists it is on
+func (h *Handler) riskReasons(categories []string) func(address string) []string {
+	return func(address string) []string {
+		var reasons []string
+		for _, label := range h.labels.Lookup(address) {
+			if slices.Contains(categories, label.Category) {
+				reasons = append(reasons, fmt.Sprintf("%s: %s", label.Category, label.Name))
+			}
+		}
+		for _, list := range h.screener.Listed(address) {
+			reasons = append(reasons, "sanctions list: "+list)
+		}
+		return reasons
+	}
+}
+
+// RiskHandler handles requests to the /risk endpoint
+func (h *Handler) RiskHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	// Validate HTTP method
+	if !helper.ensureMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	// Parse and validate parameters
+	params, ok := helper.getValidParams(w, r, h.requestTimeout)
+	if !ok {
+		return
+	}
+
+	riskParams, err := h.parseRiskParams(r.URL.Query(), params)
+	if err != nil {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	ctx, cancel := h.requestContext(r, params)
+	defer cancel()
+
+	// Score the address
+	response, err := h.risk(ctx, riskParams)
+	if err != nil {
+		helper.respondWithAnalysisError(w, err, "Failed to score risk")
+		return
+	}
+
+	// Send JSON response
+	helper.respondWithJSON(w, response)
+}
+
+// risk scores an address and creates the /risk response
+func (h *Handler) risk(ctx context.Context, params service.RiskParams) (models.RiskResponse, error) {
+	report, status, err := h.analysisService.AnalyzeRisk(ctx, params)
+	if err != nil {
+		return models.RiskResponse{}, err
+	}
+
+	return models.RiskResponse{
+		Message:     "success",
+		FetchStatus: status,
+		Data:        report,
+	}, nil
+}
@@ -8,9 +8,12 @@ import (
 )
 
 // SetupRouter sets up the HTTP router with all routes
-func SetupRouter(cfg *config.Config) http.Handler {
+func SetupRouter(cfg *config.Config) (http.Handler, error) {
 	// Create a new handler with required services
-	handler := NewHandler(cfg)
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create a new router
 	mux := http.NewServeMux()
@@ -18,9 +21,22 @@ func SetupRouter(cfg *config.Config) http.Handler {
 	// Register routes
 	mux.HandleFunc("/beneficiary", handler.BeneficiaryHandler)
 	mux.HandleFunc("/payer", handler.PayerHandler)
+	mux.HandleFunc("/trace", handler.TraceHandler)
+	mux.HandleFunc("/ledger", handler.LedgerHandler)
+	mux.HandleFunc("/counterparties", handler.CounterpartiesHandler)
+	mux.HandleFunc("/timeseries", handler.TimeSeriesHandler)
+	mux.HandleFunc("/chains", handler.ChainsHandler)
+	mux.HandleFunc("/jobs", handler.JobsHandler)
+	mux.HandleFunc("/jobs/{id}", handler.JobHandler)
+	mux.HandleFunc("/batch", handler.BatchHandler)
+	mux.HandleFunc("/labels", handler.LabelsHandler)
+	mux.HandleFunc("/labels/{address}", handler.LabelHandler)
+	mux.HandleFunc("/screening", handler.ScreeningHandler)
+	mux.HandleFunc("/screening/reload", handler.ScreeningReloadHandler)
+	mux.HandleFunc("/risk", handler.RiskHandler)
 
 	// Add middleware for logging, CORS, etc.
-	return LoggingMiddleware(mux)
+	return LoggingMiddleware(mux), nil
 }
 
 // LoggingMiddleware logs all incoming requests
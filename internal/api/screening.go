@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net/http"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/screening"
+)
+
+// transactionHashes returns the hashes of transactions
+func transactionHashes(transactions []models.Transaction) []string {
+	hashes := make([]string, 0, len(transactions))
+	for _, tx := range transactions {
+		hashes = append(hashes, tx.TransactionID)
+	}
+	return hashes
+}
+
+// beneficiaryExposures returns the beneficiaries of an address as exposures one hop away
+func beneficiaryExposures(beneficiaries []models.Beneficiary) []screening.Exposure {
+	exposures := make([]screening.Exposure, 0, len(beneficiaries))
+	for _, ben := range beneficiaries {
+		exposures = append(exposures, screening.Exposure{Address: ben.Address, Hops: 1, TxHashes: transactionHashes(ben.Transactions)})
+	}
+	return exposures
+}
+
+// payerExposures returns the payers of an address as exposures one hop away
+func payerExposures(payers []models.Payer) []screening.Exposure {
+	exposures := make([]screening.Exposure, 0, len(payers))
+	for _, payer := range payers {
+		exposures = append(exposures, screening.Exposure{Address: payer.Address, Hops: 1, TxHashes: transactionHashes(payer.Transactions)})
+	}
+	return exposures
+}
+
+// counterpartyExposures returns the counterparties of an address as exposures one hop away
+func counterpartyExposures(counterparties []models.Counterparty) []screening.Exposure {
+	exposures := make([]screening.Exposure, 0, len(counterparties))
+	for _, c := range counterparties {
+		exposures = append(exposures, screening.Exposure{Address: c.Address, Hops: 1, TxHashes: c.TxHashes})
+	}
+	return exposures
+}
+
+// timeSeriesExposures returns the counterparties of the binned transfers as exposures one hop away
+func timeSeriesExposures(series models.TimeSeries) []screening.Exposure {
+	exposures := make([]screening.Exposure, 0, len(series.Counterparties))
+	for address, hashes := range series.Counterparties {
+		exposures = append(exposures, screening.Exposure{Address: address, Hops: 1, TxHashes: hashes})
+	}
+	return exposures
+}
+
+// traceExposures returns the nodes of a trace as exposures at their depth, reached
+// through the edges leading to them from the previous hop
+func traceExposures(graph models.FlowGraph) []screening.Exposure {
+	depths := map[string]int{}
+	for _, node := range graph.Nodes {
+		depths[node.Address] = node.Depth
+	}
+
+	hashes := map[string][]string{}
+	for _, edge := range graph.Edges {
+		previous, reached := edge.From, edge.To
+		if graph.Direction == "in" {
+			previous, reached = edge.To, edge.From
+		}
+		if depths[previous] == depths[reached]-1 {
+			hashes[reached] = append(hashes[reached], edge.TxHashes...)
+		}
+	}
+
+	exposures := make([]screening.Exposure, 0, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		if node.Depth == 0 {
+			continue
+		}
+		exposures = append(exposures, screening.Exposure{Address: node.Address, Hops: node.Depth, TxHashes: hashes[node.Address]})
+	}
+	return exposures
+}
+
+// ScreeningHandler handles requests to the /screening endpoint, which describes the sanctions lists
+func (h *Handler) ScreeningHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	// Validate HTTP method
+	if !helper.ensureMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	// Send JSON response
+	helper.respondWithJSON(w, models.SanctionsListsResponse{
+		Message: "success",
+		Data:    h.screener.Status(),
+	})
+}
+
+// ScreeningReloadHandler handles requests to the /screening/reload endpoint,
+// which reloads every sanctions list from its file
+func (h *Handler) ScreeningReloadHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	// Validate HTTP method
+	if !helper.ensureMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	if err := h.screener.Reload(); err != nil {
+		helper.respondWithError(w, http.StatusInternalServerError, "reload_failed", "Failed to reload sanctions lists: "+err.Error())
+		return
+	}
+
+	// Send JSON response
+	helper.respondWithJSON(w, models.SanctionsListsResponse{
+		Message: "success",
+		Data:    h.screener.Status(),
+	})
+}
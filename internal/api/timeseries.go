@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/services"
+)
+
+// parseTimeSeriesParams extracts the time series parameters from the query
+func parseTimeSeriesParams(query url.Values, params FilterAndSortParams) (service.TimeSeriesParams, error) {
+	if err := rejectAccountFilters(params); err != nil {
+		return service.TimeSeriesParams{}, err
+	}
+
+	seriesParams := service.TimeSeriesParams{
+		AnalysisParams: httpHelper{}.toAnalysisParams(params),
+		Interval:       service.IntervalDay,
+		Asset:          params.Asset,
+	}
+
+	// Parse interval
+	if interval := query.Get("interval"); interval != "" {
+		switch strings.ToLower(interval) {
+		case service.IntervalHour, service.IntervalDay, service.IntervalWeek, service.IntervalMonth:
+			seriesParams.Interval = strings.ToLower(interval)
+		default:
+			return seriesParams, fmt.Errorf("invalid interval %q, expected hour, day, week or month", interval)
+		}
+	}
+
+	return seriesParams, nil
+}
+
+// TimeSeriesHandler handles requests to the /timeseries endpoint
+func (h *Handler) TimeSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	// Validate HTTP method
+	if !helper.ensureMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	// Parse and validate parameters
+	params, ok := helper.getValidParams(w, r, h.requestTimeout)
+	if !ok {
+		return
+	}
+
+	seriesParams, err := parseTimeSeriesParams(r.URL.Query(), params)
+	if err != nil {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	ctx, cancel := h.requestContext(r, params)
+	defer cancel()
+
+	// Bin the flows by time
+	response, err := h.timeSeries(ctx, seriesParams)
+	if err != nil {
+		helper.respondWithAnalysisError(w, err, "Failed to analyze time series")
+		return
+	}
+
+	// Send JSON response
+	helper.respondWithJSON(w, response)
+}
+
+// timeSeries runs the time series analysis and creates the /timeseries response
+func (h *Handler) timeSeries(ctx context.Context, params service.TimeSeriesParams) (models.TimeSeriesResponse, error) {
+	series, status, err := h.analysisService.AnalyzeTimeSeries(ctx, params)
+	if err != nil {
+		return models.TimeSeriesResponse{}, err
+	}
+
+	return models.TimeSeriesResponse{
+		Message:     "success",
+		FetchStatus: status,
+		Data:        series,
+		Screening:   h.screener.Screen(params.Address, timeSeriesExposures(series)),
+	}, nil
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/services"
+	"Ethereum-fund-flow-analysis/internal/utils"
+)
+
+const (
+	defaultTraceHops   = 2
+	maxTraceHops       = 5
+	defaultTraceFanOut = 10
+	maxTraceFanOut     = 50
+	defaultTraceNodes  = 100
+	maxTraceNodes      = 1000
+)
+
+// parseTraceParams extracts the multi-hop trace parameters from the query
+func parseTraceParams(query url.Values, params FilterAndSortParams) (service.TraceParams, error) {
+	if err := rejectAccountFilters(params); err != nil {
+		return service.TraceParams{}, err
+	}
+
+	traceParams := service.TraceParams{
+		AnalysisParams: httpHelper{}.toAnalysisParams(params),
+		Outgoing:       true,
+		MaxHops:        defaultTraceHops,
+		MaxFanOut:      defaultTraceFanOut,
+		MaxNodes:       defaultTraceNodes,
+		MinAmount:      nil,
+		Asset:          params.Asset,
+	}
+
+	// Parse direction
+	if direction := query.Get("direction"); direction != "" {
+		switch strings.ToLower(direction) {
+		case "out":
+			traceParams.Outgoing = true
+		case "in":
+			traceParams.Outgoing = false
+		default:
+			return traceParams, fmt.Errorf("invalid direction %q, expected \"out\" or \"in\"", direction)
+		}
+	}
+
+	// Parse hops
+	if hopsStr := query.Get("hops"); hopsStr != "" {
+		hops, err := strconv.Atoi(hopsStr)
+		if err != nil {
+			return traceParams, err
+		}
+		if hops <= 0 || hops > maxTraceHops {
+			return traceParams, fmt.Errorf("hops must be between 1 and %d", maxTraceHops)
+		}
+		traceParams.MaxHops = hops
+	}
+
+	// Parse fan-out cap
+	if fanOutStr := query.Get("fanout"); fanOutStr != "" {
+		fanOut, err := strconv.Atoi(fanOutStr)
+		if err != nil {
+			return traceParams, err
+		}
+		if fanOut <= 0 || fanOut > maxTraceFanOut {
+			return traceParams, fmt.Errorf("fanout must be between 1 and %d", maxTraceFanOut)
+		}
+		traceParams.MaxFanOut = fanOut
+	}
+
+	// Parse the budget of expanded addresses, which bounds the fetches of the whole trace
+	if maxNodesStr := query.Get("max_nodes"); maxNodesStr != "" {
+		maxNodes, err := strconv.Atoi(maxNodesStr)
+		if err != nil {
+			return traceParams, err
+		}
+		if maxNodes <= 0 || maxNodes > maxTraceNodes {
+			return traceParams, fmt.Errorf("max_nodes must be between 1 and %d", maxTraceNodes)
+		}
+		traceParams.MaxNodes = maxNodes
+	}
+
+	// Parse minimum edge amount
+	if minEdgeStr := query.Get("min_edge"); minEdgeStr != "" {
+		minEdge, err := utils.ParseDecimal(minEdgeStr)
+		if err != nil {
+			return traceParams, err
+		}
+		traceParams.MinAmount = minEdge
+	}
+
+	return traceParams, nil
+}
+
+// TraceHandler handles requests to the /trace endpoint
+func (h *Handler) TraceHandler(w http.ResponseWriter, r *http.Request) {
+	helper := httpHelper{}
+
+	// Validate HTTP method
+	if !helper.ensureMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	// Parse and validate parameters
+	params, ok := helper.getValidParams(w, r, h.requestTimeout)
+	if !ok {
+		return
+	}
+
+	traceParams, err := parseTraceParams(r.URL.Query(), params)
+	if err != nil {
+		helper.respondWithError(w, http.StatusBadRequest, "invalid_parameters", "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	format, ok := helper.getGraphFormat(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := h.requestContext(r, params)
+	defer cancel()
+
+	// Trace the flow graph
+	response, err := h.trace(ctx, traceParams)
+	if err != nil {
+		helper.respondWithAnalysisError(w, err, "Failed to trace funds")
+		return
+	}
+
+	// Render the graph export if requested
+	if format != "" {
+		helper.respondWithGraph(w, format, response.Data)
+		return
+	}
+
+	// Send JSON response
+	helper.respondWithJSON(w, response)
+}
+
+// trace runs a multi-hop trace, labels its nodes and creates the /trace response
+func (h *Handler) trace(ctx context.Context, params service.TraceParams) (models.TraceResponse, error) {
+	graph, status, err := h.analysisService.TraceFlow(ctx, params)
+	if err != nil {
+		return models.TraceResponse{}, err
+	}
+
+	for i := range graph.Nodes {
+		graph.Nodes[i].Labels = h.labels.Lookup(graph.Nodes[i].Address)
+	}
+
+	return models.TraceResponse{
+		Message:     "success",
+		FetchStatus: status,
+		Data:        graph,
+		Screening:   h.screener.Screen(params.Address, traceExposures(graph)),
+	}, nil
+}
@@ -0,0 +1,91 @@
+package chains
+
+import "Ethereum-fund-flow-analysis/internal/models"
+
+// builtin lists the chains supported by the Etherscan V2 API, all of which are served
+// by the configured base URL
+var builtin = []models.Chain{
+	chain(1, "Ethereum Mainnet", "ETH", "Ether", "https://etherscan.io", 12),
+	testnet(11155111, "Sepolia Testnet", "ETH", "Sepolia Ether", "https://sepolia.etherscan.io", 12),
+	testnet(17000, "Holesky Testnet", "ETH", "Holesky Ether", "https://holesky.etherscan.io", 12),
+	chain(2741, "Abstract Mainnet", "ETH", "Ether", "https://abscan.org", 1),
+	testnet(11124, "Abstract Sepolia Testnet", "ETH", "Sepolia Ether", "https://sepolia.abscan.org", 1),
+	chain(33139, "ApeChain Mainnet", "APE", "ApeCoin", "https://apescan.io", 0.25),
+	testnet(33111, "ApeChain Curtis Testnet", "APE", "ApeCoin", "https://curtis.apescan.io", 0.25),
+	chain(42161, "Arbitrum One Mainnet", "ETH", "Ether", "https://arbiscan.io", 0.25),
+	chain(42170, "Arbitrum Nova Mainnet", "ETH", "Ether", "https://nova.arbiscan.io", 0.25),
+	testnet(421614, "Arbitrum Sepolia Testnet", "ETH", "Sepolia Ether", "https://sepolia.arbiscan.io", 0.25),
+	chain(43114, "Avalanche C-Chain", "AVAX", "Avalanche", "https://snowscan.xyz", 2),
+	testnet(43113, "Avalanche Fuji Testnet", "AVAX", "Avalanche", "https://testnet.snowscan.xyz", 2),
+	chain(8453, "Base Mainnet", "ETH", "Ether", "https://basescan.org", 2),
+	testnet(84532, "Base Sepolia Testnet", "ETH", "Sepolia Ether", "https://sepolia.basescan.org", 2),
+	chain(80094, "Berachain Mainnet", "BERA", "Bera", "https://berascan.com", 2),
+	testnet(80069, "Berachain Bepolia Testnet", "BERA", "Bera", "https://testnet.berascan.com", 2),
+	chain(199, "BitTorrent Chain Mainnet", "BTT", "BitTorrent", "https://bttcscan.com", 2),
+	testnet(1028, "BitTorrent Chain Testnet", "BTT", "BitTorrent", "https://testnet.bttcscan.com", 2),
+	chain(81457, "Blast Mainnet", "ETH", "Ether", "https://blastscan.io", 2),
+	testnet(168587773, "Blast Sepolia Testnet", "ETH", "Sepolia Ether", "https://sepolia.blastscan.io", 2),
+	chain(56, "BNB Smart Chain Mainnet", "BNB", "BNB", "https://bscscan.com", 3),
+	testnet(97, "BNB Smart Chain Testnet", "tBNB", "Test BNB", "https://testnet.bscscan.com", 3),
+	chain(42220, "Celo Mainnet", "CELO", "Celo", "https://celoscan.io", 1),
+	testnet(44787, "Celo Alfajores Testnet", "CELO", "Celo", "https://alfajores.celoscan.io", 1),
+	chain(25, "Cronos Mainnet", "CRO", "Cronos", "https://cronoscan.com", 6),
+	chain(252, "Fraxtal Mainnet", "frxETH", "Frax Ether", "https://fraxscan.com", 2),
+	testnet(2522, "Fraxtal Testnet", "frxETH", "Frax Ether", "https://holesky.fraxscan.com", 2),
+	chain(100, "Gnosis", "xDAI", "xDAI", "https://gnosisscan.io", 5),
+	chain(59144, "Linea Mainnet", "ETH", "Ether", "https://lineascan.build", 2),
+	testnet(59141, "Linea Sepolia Testnet", "ETH", "Sepolia Ether", "https://sepolia.lineascan.build", 2),
+	chain(5000, "Mantle Mainnet", "MNT", "Mantle", "https://mantlescan.xyz", 2),
+	testnet(5003, "Mantle Sepolia Testnet", "MNT", "Mantle", "https://sepolia.mantlescan.xyz", 2),
+	chain(4352, "MemeCore Mainnet", "M", "MemeCore", "https://memecorescan.io", 0),
+	testnet(43521, "MemeCore Testnet", "M", "MemeCore", "https://formicarium.memecorescan.io", 0),
+	chain(1284, "Moonbeam Mainnet", "GLMR", "Glimmer", "https://moonscan.io", 6),
+	chain(1285, "Moonriver Mainnet", "MOVR", "Moonriver", "https://moonriver.moonscan.io", 6),
+	testnet(1287, "Moonbase Alpha Testnet", "DEV", "Dev", "https://moonbase.moonscan.io", 6),
+	chain(10, "OP Mainnet", "ETH", "Ether", "https://optimistic.etherscan.io", 2),
+	testnet(11155420, "OP Sepolia Testnet", "ETH", "Sepolia Ether", "https://sepolia-optimism.etherscan.io", 2),
+	chain(137, "Polygon Mainnet", "POL", "POL", "https://polygonscan.com", 2),
+	testnet(80002, "Polygon Amoy Testnet", "POL", "POL", "https://amoy.polygonscan.com", 2),
+	chain(1101, "Polygon zkEVM Mainnet", "ETH", "Ether", "https://zkevm.polygonscan.com", 0),
+	testnet(2442, "Polygon zkEVM Cardona Testnet", "ETH", "Sepolia Ether", "https://cardona-zkevm.polygonscan.com", 0),
+	chain(534352, "Scroll Mainnet", "ETH", "Ether", "https://scrollscan.com", 3),
+	testnet(534351, "Scroll Sepolia Testnet", "ETH", "Sepolia Ether", "https://sepolia.scrollscan.com", 3),
+	chain(146, "Sonic Mainnet", "S", "Sonic", "https://sonicscan.org", 1),
+	testnet(57054, "Sonic Blaze Testnet", "S", "Sonic", "https://testnet.sonicscan.org", 1),
+	chain(50104, "Sophon Mainnet", "SOPH", "Sophon", "https://sophscan.xyz", 1),
+	testnet(531050104, "Sophon Sepolia Testnet", "SOPH", "Sophon", "https://testnet.sophscan.xyz", 1),
+	chain(1923, "Swellchain Mainnet", "ETH", "Ether", "https://swellchainscan.io", 2),
+	testnet(1924, "Swellchain Testnet", "ETH", "Sepolia Ether", "https://sepolia.swellchainscan.io", 2),
+	chain(167000, "Taiko Mainnet", "ETH", "Ether", "https://taikoscan.io", 0),
+	testnet(167009, "Taiko Hekla Testnet", "ETH", "Holesky Ether", "https://hekla.taikoscan.io", 0),
+	chain(130, "Unichain Mainnet", "ETH", "Ether", "https://uniscan.xyz", 1),
+	testnet(1301, "Unichain Sepolia Testnet", "ETH", "Sepolia Ether", "https://sepolia.uniscan.xyz", 1),
+	chain(1111, "WEMIX3.0 Mainnet", "WEMIX", "WEMIX", "https://wemixscan.com", 1),
+	testnet(1112, "WEMIX3.0 Testnet", "WEMIX", "WEMIX", "https://testnet.wemixscan.com", 1),
+	chain(480, "World Chain Mainnet", "ETH", "Ether", "https://worldscan.org", 2),
+	testnet(4801, "World Chain Sepolia Testnet", "ETH", "Sepolia Ether", "https://sepolia.worldscan.org", 2),
+	chain(660279, "Xai Mainnet", "XAI", "Xai", "https://xaiscan.io", 0.25),
+	testnet(37714555429, "Xai Sepolia Testnet", "XAI", "Xai", "https://sepolia.xaiscan.io", 0.25),
+	chain(50, "XDC Mainnet", "XDC", "XDC", "https://xdcscan.com", 2),
+	testnet(51, "XDC Apothem Testnet", "TXDC", "Test XDC", "https://testnet.xdcscan.com", 2),
+	chain(324, "zkSync Mainnet", "ETH", "Ether", "https://era.zksync.network", 1),
+	testnet(300, "zkSync Sepolia Testnet", "ETH", "Sepolia Ether", "https://sepolia-era.zksync.network", 1),
+}
+
+// testnet describes a built-in test network whose native coin has 18 decimals
+func testnet(id int, name, symbol, coin, explorer string, blockTime float64) models.Chain {
+	c := chain(id, name, symbol, coin, explorer, blockTime)
+	c.Testnet = true
+	return c
+}
+
+// chain describes a built-in chain whose native coin has 18 decimals
+func chain(id int, name, symbol, coin, explorer string, blockTime float64) models.Chain {
+	return models.Chain{
+		ID:          id,
+		Name:        name,
+		Native:      models.NativeCurrency{Symbol: symbol, Name: coin, Decimals: 18},
+		ExplorerURL: explorer,
+		BlockTime:   blockTime,
+	}
+}
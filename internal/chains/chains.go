@@ -0,0 +1,144 @@
+package chains
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// defaultDecimals is the number of decimals assumed for a native coin that does not specify them
+const defaultDecimals = 18
+
+// Registry holds the chains that can be analyzed, keyed by chain ID
+type Registry struct {
+	chains map[int]models.Chain
+}
+
+// New creates a registry of the given chains
+func New(chains ...models.Chain) *Registry {
+	r := &Registry{chains: make(map[int]models.Chain, len(chains))}
+	for _, chain := range chains {
+		r.chains[chain.ID] = chain
+	}
+	return r
+}
+
+// fileEntry is a chain in a registry file
+type fileEntry struct {
+	models.Chain
+	Disabled bool  `json:"disabled"` // Removes a built-in chain
+	Testnet  *bool `json:"testnet"`  // Overrides the testnet flag of a built-in chain if set
+}
+
+// Load creates a registry of the built-in chains, merged with the JSON array of chains in
+// the file at path if it is not empty. An entry for a built-in chain only overrides the
+// fields it sets; an entry for any other chain adds it and must name the chain and its coin.
+func Load(path string) (*Registry, error) {
+	r := New(builtin...)
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chain registry: %w", err)
+	}
+
+	var entries []fileEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse chain registry %s: %w", path, err)
+	}
+
+	for _, entry := range entries {
+		if entry.ID <= 0 {
+			return nil, fmt.Errorf("invalid chain registry %s: chain_id must be positive", path)
+		}
+		if entry.Disabled {
+			delete(r.chains, entry.ID)
+			continue
+		}
+
+		chain, ok := r.chains[entry.ID]
+		if !ok {
+			chain = models.Chain{ID: entry.ID, Native: models.NativeCurrency{Decimals: defaultDecimals}}
+		}
+		chain = merge(chain, entry.Chain)
+		if entry.Testnet != nil {
+			chain.Testnet = *entry.Testnet
+		}
+
+		if chain.Name == "" || chain.Native.Symbol == "" {
+			return nil, fmt.Errorf("invalid chain registry %s: chain %d needs a name and a native currency symbol", path, entry.ID)
+		}
+		if chain.Native.Name == "" {
+			chain.Native.Name = chain.Native.Symbol
+		}
+		r.chains[entry.ID] = chain
+	}
+
+	if len(r.chains) == 0 {
+		return nil, errors.New("chain registry is empty")
+	}
+
+	return r, nil
+}
+
+// merge overrides the fields of chain that are set in override
+func merge(chain, override models.Chain) models.Chain {
+	if override.Name != "" {
+		chain.Name = override.Name
+	}
+	if override.Native.Symbol != "" {
+		chain.Native.Symbol = override.Native.Symbol
+	}
+	if override.Native.Name != "" {
+		chain.Native.Name = override.Native.Name
+	}
+	if override.Native.Decimals != 0 {
+		chain.Native.Decimals = override.Native.Decimals
+	}
+	if override.ExplorerURL != "" {
+		chain.ExplorerURL = strings.TrimRight(override.ExplorerURL, "/")
+	}
+	if override.APIURL != "" {
+		chain.APIURL = override.APIURL
+	}
+	if override.BlockTime != 0 {
+		chain.BlockTime = override.BlockTime
+	}
+	return chain
+}
+
+// Get returns the chain with the given ID
+func (r *Registry) Get(id int) (models.Chain, bool) {
+	chain, ok := r.chains[id]
+	return chain, ok
+}
+
+// List returns every chain ordered by chain ID
+func (r *Registry) List() []models.Chain {
+	list := make([]models.Chain, 0, len(r.chains))
+	for _, chain := range r.chains {
+		list = append(list, chain)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// APIURLs returns the API base URL of every chain that has its own
+func (r *Registry) APIURLs() map[int]string {
+	urls := map[int]string{}
+	for id, chain := range r.chains {
+		if chain.APIURL != "" {
+			urls[id] = chain.APIURL
+		}
+	}
+	return urls
+}
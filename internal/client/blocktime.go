@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Directions in which BlockByTime looks for the closest block
+const (
+	ClosestBefore = "before" // The last block mined at or before the time
+	ClosestAfter  = "after"  // The first block mined at or after the time
+)
+
+// blockTimeFinality is how old a time must be before the block resolved for it is cached,
+// since the answer for recent times may still change
+const blockTimeFinality = time.Hour
+
+// BlockResolver is implemented by sources that can resolve a time to a block number
+type BlockResolver interface {
+	// BlockByTime returns the closest block before or after t. apiKey overrides the
+	// source's API key if set.
+	BlockByTime(ctx context.Context, chainId int, apiKey string, t time.Time, closest string) (int64, error)
+}
+
+// blockTimeKey identifies a resolved time
+type blockTimeKey struct {
+	chainId int
+	unix    int64
+	closest string
+}
+
+// blockTimeCache remembers the blocks resolved for times that are old enough not to change
+type blockTimeCache struct {
+	mu     sync.Mutex
+	blocks map[blockTimeKey]int64
+}
+
+// BlockByTime resolves a time to the closest block before or after it using
+// Etherscan's getblocknobytime action. Results for past times are cached.
+func (c *Client) BlockByTime(ctx context.Context, chainId int, apiKey string, t time.Time, closest string) (int64, error) {
+	key := blockTimeKey{chainId: chainId, unix: t.Unix(), closest: closest}
+
+	c.blockTimes.mu.Lock()
+	block, ok := c.blockTimes.blocks[key]
+	c.blockTimes.mu.Unlock()
+	if ok {
+		return block, nil
+	}
+
+	endpoint := fmt.Sprintf("%s?chainid=%d&module=block&action=getblocknobytime&timestamp=%d&closest=%s",
+		c.chainURL(chainId), chainId, t.Unix(), closest)
+	apiKey = c.requestAPIKey(EtherscanRequestParams{ApiKey: apiKey})
+	if apiKey != "" {
+		endpoint += fmt.Sprintf("&apikey=%s", apiKey)
+	}
+
+	var result string
+	if err := c.makeRequest(ctx, endpoint, apiKey, &result); err != nil {
+		return 0, err
+	}
+
+	block, err := strconv.ParseInt(result, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing block number %q: %w", result, err)
+	}
+
+	if time.Since(t) > blockTimeFinality {
+		c.blockTimes.mu.Lock()
+		c.blockTimes.blocks[key] = block
+		c.blockTimes.mu.Unlock()
+	}
+
+	return block, nil
+}
+
+// resolveBlockByTime resolves a time through source if it supports it
+func resolveBlockByTime(ctx context.Context, source TransactionSource, chainId int, apiKey string, t time.Time, closest string) (int64, error) {
+	resolver, ok := source.(BlockResolver)
+	if !ok {
+		return 0, fmt.Errorf("source cannot resolve times to blocks: %w", errors.ErrUnsupported)
+	}
+	return resolver.BlockByTime(ctx, chainId, apiKey, t, closest)
+}
+
+// BlockByTime resolves a time through the wrapped source
+func (s *CachedSource) BlockByTime(ctx context.Context, chainId int, apiKey string, t time.Time, closest string) (int64, error) {
+	return resolveBlockByTime(ctx, s.inner, chainId, apiKey, t, closest)
+}
+
+// BlockByTime resolves a time through the wrapped source
+func (s *SyncedSource) BlockByTime(ctx context.Context, chainId int, apiKey string, t time.Time, closest string) (int64, error) {
+	return resolveBlockByTime(ctx, s.inner, chainId, apiKey, t, closest)
+}
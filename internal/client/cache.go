@@ -0,0 +1,416 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// CacheOptions configures the response cache
+type CacheOptions struct {
+	MaxBytes      int           // Memory budget of the LRU cache
+	Dir           string        // Directory of the on-disk cache, empty to keep entries in memory only
+	TTL           time.Duration // Lifetime of entries whose block range may still change
+	FinalizedTTL  time.Duration // Lifetime of entries whose block range is finalized
+	FinalityDepth int64         // Blocks behind the chain head after which a block is considered final
+}
+
+// BlockHeightSource is implemented by sources that know the latest block of a chain
+type BlockHeightSource interface {
+	// LatestBlock returns the latest block of the chain. apiKey overrides the source's
+	// API key if set.
+	LatestBlock(ctx context.Context, chainId int, apiKey string) (int64, error)
+}
+
+// ResponseCache stores fetched transaction lists in an in-memory LRU,
+// optionally backed by files on disk
+type ResponseCache struct {
+	options CacheOptions
+	mu      sync.Mutex
+	lru     *list.List
+	items   map[string]*list.Element
+	size    int
+}
+
+// cacheEntry is a cached transaction list
+type cacheEntry struct {
+	Key       string          `json:"key"`
+	Data      json.RawMessage `json:"data"`
+	Expires   time.Time       `json:"expires"`
+	Truncated bool            `json:"truncated"`
+	Empty     bool            `json:"empty"` // The source reported no transactions
+}
+
+// NewResponseCache creates a cache with the given options
+func NewResponseCache(options CacheOptions) (*ResponseCache, error) {
+	if options.Dir != "" {
+		if err := os.MkdirAll(options.Dir, 0o755); err != nil {
+			return nil, fmt.Errorf("error creating cache directory: %w", err)
+		}
+	}
+
+	return &ResponseCache{
+		options: options,
+		lru:     list.New(),
+		items:   map[string]*list.Element{},
+	}, nil
+}
+
+// get returns the live entry stored under key, looking on disk after a memory miss
+func (c *ResponseCache) get(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(cacheEntry)
+		if time.Now().Before(entry.Expires) {
+			c.lru.MoveToFront(elem)
+			c.mu.Unlock()
+			return entry, true
+		}
+		c.remove(elem)
+	}
+	c.mu.Unlock()
+
+	entry, ok := c.readFile(key)
+	if !ok {
+		return cacheEntry{}, false
+	}
+
+	c.mu.Lock()
+	c.add(entry)
+	c.mu.Unlock()
+	return entry, true
+}
+
+// set stores an entry in memory and on disk
+func (c *ResponseCache) set(entry cacheEntry) {
+	c.mu.Lock()
+	c.add(entry)
+	c.mu.Unlock()
+
+	c.writeFile(entry)
+}
+
+// add inserts or replaces an entry in memory, evicting the least recently used
+// entries to stay within the memory budget. The caller must hold c.mu.
+func (c *ResponseCache) add(entry cacheEntry) {
+	if elem, ok := c.items[entry.Key]; ok {
+		c.remove(elem)
+	}
+	if len(entry.Data) > c.options.MaxBytes {
+		return
+	}
+
+	c.items[entry.Key] = c.lru.PushFront(entry)
+	c.size += len(entry.Data)
+
+	for c.size > c.options.MaxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove deletes an element from memory. The caller must hold c.mu.
+func (c *ResponseCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(cacheEntry)
+	delete(c.items, entry.Key)
+	c.size -= len(entry.Data)
+}
+
+// filePath returns the on-disk location of the entry stored under key
+func (c *ResponseCache) filePath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.options.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readFile loads a live entry from disk
+func (c *ResponseCache) readFile(key string) (cacheEntry, bool) {
+	if c.options.Dir == "" {
+		return cacheEntry{}, false
+	}
+
+	data, err := os.ReadFile(c.filePath(key))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return cacheEntry{}, false
+	}
+	if !time.Now().Before(entry.Expires) {
+		os.Remove(c.filePath(key))
+		return cacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+// writeFile persists an entry to disk, replacing the previous file atomically
+func (c *ResponseCache) writeFile(entry cacheEntry) {
+	if c.options.Dir == "" {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(c.options.Dir, "entry-*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.filePath(entry.Key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// CachedSource is a transaction source answering from a ResponseCache
+// and falling back to the wrapped source
+type CachedSource struct {
+	name  string // Distinguishes sources sharing a cache
+	inner TransactionSource
+	cache *ResponseCache
+	heads *chainHeads
+}
+
+// NewCachedSource wraps the source registered as name with the cache
+func NewCachedSource(name string, inner TransactionSource, cache *ResponseCache) *CachedSource {
+	return &CachedSource{
+		name:  name,
+		inner: inner,
+		cache: cache,
+		heads: newChainHeads(inner),
+	}
+}
+
+// GetNormalTransactions fetches normal transactions through the cache
+func (s *CachedSource) GetNormalTransactions(ctx context.Context, params EtherscanRequestParams) ([]models.NormalTx, error) {
+	return cachedFetch(ctx, s, "txlist", params, s.inner.GetNormalTransactions)
+}
+
+// GetInternalTransactions fetches internal transactions through the cache
+func (s *CachedSource) GetInternalTransactions(ctx context.Context, params EtherscanRequestParams) ([]models.InternalTx, error) {
+	return cachedFetch(ctx, s, "txlistinternal", params, s.inner.GetInternalTransactions)
+}
+
+// GetERC20Transfers fetches ERC-20 token transfers through the cache
+func (s *CachedSource) GetERC20Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC20Transfer, error) {
+	return cachedFetch(ctx, s, "tokentx", params, s.inner.GetERC20Transfers)
+}
+
+// GetERC721Transfers fetches ERC-721 token transfers through the cache
+func (s *CachedSource) GetERC721Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC721Transfer, error) {
+	return cachedFetch(ctx, s, "tokennfttx", params, s.inner.GetERC721Transfers)
+}
+
+// GetERC1155Transfers fetches ERC-1155 token transfers through the cache
+func (s *CachedSource) GetERC1155Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC1155Transfer, error) {
+	return cachedFetch(ctx, s, "token1155tx", params, s.inner.GetERC1155Transfers)
+}
+
+// LatestBlock fetches the chain head from the wrapped source if it supports it
+func (s *CachedSource) LatestBlock(ctx context.Context, chainId int, apiKey string) (int64, error) {
+	heights, ok := s.inner.(BlockHeightSource)
+	if !ok {
+		return 0, fmt.Errorf("source %q cannot report the latest block: %w", s.name, errors.ErrUnsupported)
+	}
+	return heights.LatestBlock(ctx, chainId, apiKey)
+}
+
+// key identifies a request by source, chain, action, address, block range and paging
+func (s *CachedSource) key(action string, params EtherscanRequestParams) string {
+	return fmt.Sprintf("%s/%d/%s/%s/%s/%d-%d/%d-%d/%s/%t",
+		s.name, params.ChainId, action, strings.ToLower(params.Address), strings.ToLower(params.ContractAddress),
+		params.StartBlock, params.EndBlock, params.Page, params.Offset, params.Sort, params.Exhaustive)
+}
+
+// ttl returns the lifetime of an entry for params: long if the requested block range is
+// finalized, and short if it extends to blocks that may still change
+func (s *CachedSource) ttl(ctx context.Context, params EtherscanRequestParams) time.Duration {
+	options := s.cache.options
+	if params.EndBlock < 0 {
+		return options.TTL
+	}
+
+	head, err := s.heads.latest(ctx, params.ChainId, params.ApiKey)
+	if err != nil {
+		return options.TTL
+	}
+
+	if params.EndBlock <= head-options.FinalityDepth {
+		return options.FinalizedTTL
+	}
+	return options.TTL
+}
+
+// chainHeadTTL is how long a fetched chain head is reused to decide finality
+const chainHeadTTL = 15 * time.Second
+
+// chainHeads remembers the recently fetched chain head of every chain
+type chainHeads struct {
+	source  TransactionSource
+	mu      sync.Mutex
+	heights map[int]cachedHeight
+}
+
+// cachedHeight is a recently fetched chain head
+type cachedHeight struct {
+	block   int64
+	fetched time.Time
+}
+
+// newChainHeads tracks the chain heads reported by source
+func newChainHeads(source TransactionSource) *chainHeads {
+	return &chainHeads{
+		source:  source,
+		heights: map[int]cachedHeight{},
+	}
+}
+
+// latest returns the latest block of a chain, fetching it with apiKey if the remembered one is stale
+func (h *chainHeads) latest(ctx context.Context, chainId int, apiKey string) (int64, error) {
+	heights, ok := h.source.(BlockHeightSource)
+	if !ok {
+		return 0, fmt.Errorf("source cannot report the latest block: %w", errors.ErrUnsupported)
+	}
+
+	h.mu.Lock()
+	head, cached := h.heights[chainId]
+	h.mu.Unlock()
+
+	if cached && time.Since(head.fetched) <= chainHeadTTL {
+		return head.block, nil
+	}
+
+	block, err := heights.LatestBlock(ctx, chainId, apiKey)
+	if err != nil {
+		return 0, err
+	}
+
+	h.mu.Lock()
+	h.heights[chainId] = cachedHeight{block: block, fetched: time.Now()}
+	h.mu.Unlock()
+
+	return block, nil
+}
+
+// cachedFetch answers a request from the cache, or fetches it and caches the result.
+// Truncated results and "no transactions" answers are cached along with their error.
+func cachedFetch[T any](
+	ctx context.Context,
+	s *CachedSource,
+	action string,
+	params EtherscanRequestParams,
+	fetch func(ctx context.Context, params EtherscanRequestParams) ([]T, error),
+) ([]T, error) {
+	stats := cacheStatsFrom(ctx)
+	key := s.key(action, params)
+
+	if entry, ok := s.cache.get(key); ok {
+		var items []T
+		if err := json.Unmarshal(entry.Data, &items); err == nil {
+			stats.recordHit()
+			switch {
+			case entry.Empty:
+				return nil, ErrNoTransactions
+			case entry.Truncated:
+				return items, ErrTruncated
+			}
+			return items, nil
+		}
+	}
+	stats.recordMiss()
+
+	items, err := fetch(ctx, params)
+	empty := errors.Is(err, ErrNoTransactions)
+	truncated := errors.Is(err, ErrTruncated)
+	if err != nil && !empty && !truncated {
+		return items, err
+	}
+
+	data, marshalErr := json.Marshal(items)
+	if marshalErr != nil {
+		return items, err
+	}
+
+	s.cache.set(cacheEntry{
+		Key:       key,
+		Data:      data,
+		Expires:   time.Now().Add(s.ttl(ctx, params)),
+		Truncated: truncated,
+		Empty:     empty,
+	})
+
+	return items, err
+}
+
+// CacheStats counts cache hits and misses while serving one request
+type CacheStats struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// cacheStatsKey is the context key of the request's CacheStats
+type cacheStatsKey struct{}
+
+// WithCacheStats returns a context carrying the CacheStats that cached sources record into,
+// reusing the stats already carried by ctx if any
+func WithCacheStats(ctx context.Context) (context.Context, *CacheStats) {
+	if stats := cacheStatsFrom(ctx); stats != nil {
+		return ctx, stats
+	}
+	stats := &CacheStats{}
+	return context.WithValue(ctx, cacheStatsKey{}, stats), stats
+}
+
+// cacheStatsFrom returns the CacheStats carried by ctx, or nil
+func cacheStatsFrom(ctx context.Context) *CacheStats {
+	stats, _ := ctx.Value(cacheStatsKey{}).(*CacheStats)
+	return stats
+}
+
+// recordHit counts a cache hit
+func (s *CacheStats) recordHit() {
+	if s != nil {
+		s.hits.Add(1)
+	}
+}
+
+// recordMiss counts a cache miss
+func (s *CacheStats) recordMiss() {
+	if s != nil {
+		s.misses.Add(1)
+	}
+}
+
+// Status returns the counts for response metadata, or nil if the cache was not used
+func (s *CacheStats) Status() *models.CacheStatus {
+	if s == nil {
+		return nil
+	}
+
+	hits, misses := s.hits.Load(), s.misses.Load()
+	if hits+misses == 0 {
+		return nil
+	}
+	return &models.CacheStatus{Hits: int(hits), Misses: int(misses)}
+}
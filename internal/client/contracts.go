@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// contractCreationBatch is the number of addresses Etherscan's getcontractcreation accepts per request
+const contractCreationBatch = 5
+
+// notContractTTL is how long an address found not to be a contract is remembered,
+// as a contract may be deployed to it later
+const notContractTTL = time.Hour
+
+// ContractResolver is implemented by sources that can look up how contracts were created
+type ContractResolver interface {
+	// ContractCreations returns the creation of every contract among addresses, keyed by
+	// lowercase address. Addresses that are not contracts are left out. apiKey overrides
+	// the source's API key if set.
+	ContractCreations(ctx context.Context, chainId int, apiKey string, addresses []string) (map[string]models.ContractCreation, error)
+}
+
+// contractKey identifies a contract
+type contractKey struct {
+	chainId int
+	address string
+}
+
+// contractCache remembers contract creations, which never change, and for a while
+// the addresses that are not contracts
+type contractCache struct {
+	mu           sync.Mutex
+	creations    map[contractKey]models.ContractCreation
+	notContracts map[contractKey]time.Time // When each address was found not to be a contract
+}
+
+// newContractCache creates an empty contract cache
+func newContractCache() *contractCache {
+	return &contractCache{
+		creations:    map[contractKey]models.ContractCreation{},
+		notContracts: map[contractKey]time.Time{},
+	}
+}
+
+// ContractCreations looks up contract creations using Etherscan's getcontractcreation action,
+// a few addresses per request. Creations are cached, and so are addresses that are not
+// contracts for notContractTTL.
+func (c *Client) ContractCreations(ctx context.Context, chainId int, apiKey string, addresses []string) (map[string]models.ContractCreation, error) {
+	creations := map[string]models.ContractCreation{}
+	apiKey = c.requestAPIKey(EtherscanRequestParams{ApiKey: apiKey})
+
+	// Serve what is cached
+	var missing []string
+	c.contracts.mu.Lock()
+	for _, address := range addresses {
+		address = strings.ToLower(address)
+		key := contractKey{chainId, address}
+		if creation, ok := c.contracts.creations[key]; ok {
+			creations[address] = creation
+		} else if checked, ok := c.contracts.notContracts[key]; ok && time.Since(checked) < notContractTTL {
+			continue
+		} else {
+			missing = append(missing, address)
+		}
+	}
+	c.contracts.mu.Unlock()
+
+	for start := 0; start < len(missing); start += contractCreationBatch {
+		batch := missing[start:min(start+contractCreationBatch, len(missing))]
+
+		endpoint := fmt.Sprintf("%s?chainid=%d&module=contract&action=getcontractcreation&contractaddresses=%s",
+			c.chainURL(chainId), chainId, strings.Join(batch, ","))
+		if apiKey != "" {
+			endpoint += fmt.Sprintf("&apikey=%s", apiKey)
+		}
+
+		var result []models.ContractCreation
+		err := c.makeRequest(ctx, endpoint, apiKey, &result)
+		// None of the addresses is a contract
+		if err != nil && !errors.Is(err, ErrNoTransactions) {
+			return nil, fmt.Errorf("error looking up contract creations: %w", err)
+		}
+
+		now := time.Now()
+		c.contracts.mu.Lock()
+		for _, creation := range result {
+			address := strings.ToLower(creation.ContractAddress)
+			creations[address] = creation
+			c.contracts.creations[contractKey{chainId, address}] = creation
+		}
+		for _, address := range batch {
+			if _, ok := creations[address]; !ok {
+				c.contracts.notContracts[contractKey{chainId, address}] = now
+			}
+		}
+		c.contracts.mu.Unlock()
+	}
+
+	return creations, nil
+}
+
+// resolveContractCreations looks up contract creations through source if it supports it
+func resolveContractCreations(ctx context.Context, source TransactionSource, chainId int, apiKey string, addresses []string) (map[string]models.ContractCreation, error) {
+	resolver, ok := source.(ContractResolver)
+	if !ok {
+		return nil, fmt.Errorf("source cannot look up contract creations: %w", errors.ErrUnsupported)
+	}
+	return resolver.ContractCreations(ctx, chainId, apiKey, addresses)
+}
+
+// ContractCreations looks up contract creations through the wrapped source
+func (s *CachedSource) ContractCreations(ctx context.Context, chainId int, apiKey string, addresses []string) (map[string]models.ContractCreation, error) {
+	return resolveContractCreations(ctx, s.inner, chainId, apiKey, addresses)
+}
+
+// ContractCreations looks up contract creations through the wrapped source
+func (s *SyncedSource) ContractCreations(ctx context.Context, chainId int, apiKey string, addresses []string) (map[string]models.ContractCreation, error) {
+	return resolveContractCreations(ctx, s.inner, chainId, apiKey, addresses)
+}
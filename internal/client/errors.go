@@ -0,0 +1,66 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error kinds reported by the Etherscan API, matched with errors.Is
+var (
+	ErrRateLimited    = errors.New("rate limit reached")
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrNoTransactions = errors.New("no transactions found")
+	ErrInvalidChain   = errors.New("invalid or unsupported chain")
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrUpstream       = errors.New("upstream unavailable")
+)
+
+// APIError is an error reported by the Etherscan API, either through a "NOTOK"
+// response envelope or an unexpected HTTP response
+type APIError struct {
+	Kind       error  // One of the error kinds above
+	StatusCode int    // HTTP status code of the response
+	Message    string // Message field of the envelope
+	Result     string // Result field of the envelope, which holds the error description
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	detail := e.Result
+	if detail == "" {
+		detail = e.Message
+	}
+	if detail == "" {
+		return e.Kind.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Kind, detail)
+}
+
+// Unwrap returns the error kind
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// classifyEnvelope determines the error kind of a response envelope with status "0"
+func classifyEnvelope(message, result string) error {
+	text := strings.ToLower(message + " " + result)
+
+	switch {
+	case strings.Contains(text, "no transactions found"),
+		strings.Contains(text, "no records found"),
+		strings.Contains(text, "no data found"):
+		return ErrNoTransactions
+	case strings.Contains(text, "rate limit"):
+		return ErrRateLimited
+	case strings.Contains(text, "api key"), strings.Contains(text, "apikey"):
+		return ErrInvalidAPIKey
+	case strings.Contains(text, "chainid"), strings.Contains(text, "chain id"):
+		return ErrInvalidChain
+	case strings.Contains(text, "invalid"), strings.Contains(text, "result window is too large"),
+		strings.Contains(text, "no closest block"):
+		return ErrInvalidRequest
+	default:
+		return ErrUpstream
+	}
+}
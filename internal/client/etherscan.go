@@ -1,10 +1,15 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"Ethereum-fund-flow-analysis/internal/models"
@@ -15,17 +20,44 @@ type Client struct {
 	baseURL    string
 	apiKey     string
 	httpClient *http.Client
+	limiter    *rateLimiter
+	options    Options
+	blockTimes *blockTimeCache
+	contracts  *contractCache
+}
+
+// Options configures timeouts, rate limiting and retries of a Client
+type Options struct {
+	Timeout        time.Duration  // Timeout of a single HTTP request
+	RateLimit      float64        // Requests per second allowed per API key, 0 disables limiting
+	RateBurst      int            // Requests allowed in a burst per API key
+	MaxRetries     int            // Retries after rate limit responses, 5xx responses and timeouts
+	RetryBaseDelay time.Duration  // Delay before the first retry, doubled on every attempt
+	RetryMaxDelay  time.Duration  // Upper bound of the retry delay
+	ChainURLs      map[int]string // API base URLs of chains not served by the base URL, by chain ID
 }
 
 // NewClient creates a new Etherscan API client
-func NewClient(baseURL, apiKey string) *Client {
+func NewClient(baseURL, apiKey string, options Options) *Client {
 	return &Client{
 		baseURL:    baseURL,
 		apiKey:     apiKey,
-		httpClient: &http.Client{Timeout: 15 * time.Second},
+		httpClient: &http.Client{Timeout: options.Timeout},
+		limiter:    newRateLimiter(options.RateLimit, options.RateBurst),
+		options:    options,
+		blockTimes: &blockTimeCache{blocks: map[blockTimeKey]int64{}},
+		contracts:  newContractCache(),
 	}
 }
 
+// chainURL returns the API base URL serving the given chain
+func (c *Client) chainURL(chainId int) string {
+	if url, ok := c.options.ChainURLs[chainId]; ok {
+		return url
+	}
+	return c.baseURL
+}
+
 // EtherscanRequestParams contains all possible parameters for Etherscan API requests
 type EtherscanRequestParams struct {
 	Address         string
@@ -37,107 +69,81 @@ type EtherscanRequestParams struct {
 	EndBlock        int64
 	Sort            string // "asc" or "desc"
   ApiKey          string
+	Exhaustive      bool // Walk every page of the block range instead of fetching Page only
 }
 
 // GetNormalTransactions fetches normal transactions for the given address
-func (c *Client) GetNormalTransactions(params EtherscanRequestParams) ([]models.NormalTx, error) {
-	endpoint := c.buildEndpoint("txlist", params)
-
-	var response models.EtherscanResponse
-	response.Result = &[]models.NormalTx{}
-
-	if err := c.makeRequest(endpoint, &response); err != nil {
-		return nil, err
-	}
-
-	result, ok := response.Result.(*[]models.NormalTx)
-	if !ok {
-		return nil, fmt.Errorf("failed to parse normal transactions response")
-	}
-
-	return *result, nil
+func (c *Client) GetNormalTransactions(ctx context.Context, params EtherscanRequestParams) ([]models.NormalTx, error) {
+	return fetchTransactions[models.NormalTx](ctx, c, "txlist", params)
 }
 
 // GetInternalTransactions fetches internal transactions for the given address
-func (c *Client) GetInternalTransactions(params EtherscanRequestParams) ([]models.InternalTx, error) {
-	endpoint := c.buildEndpoint("txlistinternal", params)
-
-	var response models.EtherscanResponse
-	response.Result = &[]models.InternalTx{}
-
-	if err := c.makeRequest(endpoint, &response); err != nil {
-		return nil, err
-	}
-
-	result, ok := response.Result.(*[]models.InternalTx)
-	if !ok {
-		return nil, fmt.Errorf("failed to parse internal transactions response")
-	}
-
-	return *result, nil
+func (c *Client) GetInternalTransactions(ctx context.Context, params EtherscanRequestParams) ([]models.InternalTx, error) {
+	return fetchTransactions[models.InternalTx](ctx, c, "txlistinternal", params)
 }
 
 // GetERC20Transfers fetches ERC-20 token transfers for the given address
-func (c *Client) GetERC20Transfers(params EtherscanRequestParams) ([]models.ERC20Transfer, error) {
-	endpoint := c.buildEndpoint("tokentx", params)
-
-	var response models.EtherscanResponse
-	response.Result = &[]models.ERC20Transfer{}
-
-	if err := c.makeRequest(endpoint, &response); err != nil {
-		return nil, err
-	}
-
-	result, ok := response.Result.(*[]models.ERC20Transfer)
-	if !ok {
-		return nil, fmt.Errorf("failed to parse token transfers response")
-	}
-
-	return *result, nil
+func (c *Client) GetERC20Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC20Transfer, error) {
+	return fetchTransactions[models.ERC20Transfer](ctx, c, "tokentx", params)
 }
 
 // GetERC721Transfers fetches ERC-721 token transfers for the given address
-func (c *Client) GetERC721Transfers(params EtherscanRequestParams) ([]models.ERC721Transfer, error) {
-	endpoint := c.buildEndpoint("tokennfttx", params)
+func (c *Client) GetERC721Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC721Transfer, error) {
+	return fetchTransactions[models.ERC721Transfer](ctx, c, "tokennfttx", params)
+}
 
-	var response models.EtherscanResponse
-	response.Result = &[]models.ERC721Transfer{}
+// GetERC1155Transfers fetches ERC-1155 token transfers for the given address
+func (c *Client) GetERC1155Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC1155Transfer, error) {
+	return fetchTransactions[models.ERC1155Transfer](ctx, c, "token1155tx", params)
+}
 
-	if err := c.makeRequest(endpoint, &response); err != nil {
-		return nil, err
+// LatestBlock fetches the number of the most recent block of the chain
+func (c *Client) LatestBlock(ctx context.Context, chainId int, apiKey string) (int64, error) {
+	endpoint := fmt.Sprintf("%s?chainid=%d&module=proxy&action=eth_blockNumber", c.chainURL(chainId), chainId)
+	apiKey = c.requestAPIKey(EtherscanRequestParams{ApiKey: apiKey})
+	if apiKey != "" {
+		endpoint += fmt.Sprintf("&apikey=%s", apiKey)
 	}
 
-	result, ok := response.Result.(*[]models.ERC721Transfer)
-	if !ok {
-		return nil, fmt.Errorf("failed to parse token transfers response")
+	var result string
+	if err := c.makeRequest(ctx, endpoint, apiKey, &result); err != nil {
+		return 0, err
 	}
 
-	return *result, nil
+	block, err := strconv.ParseInt(strings.TrimPrefix(result, "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing block number %q: %w", result, err)
+	}
+
+	return block, nil
 }
 
-// GetERC1155Transfers fetches ERC-1155 token transfers for the given address
-func (c *Client) GetERC1155Transfers(params EtherscanRequestParams) ([]models.ERC1155Transfer, error) {
-	endpoint := c.buildEndpoint("token1155tx", params)
+// fetchTransactions fetches a single page of the given action, or every page of
+// the block range if params.Exhaustive is set
+func fetchTransactions[T blockItem](ctx context.Context, c *Client, action string, params EtherscanRequestParams) ([]T, error) {
+	fetchPage := func(ctx context.Context, params EtherscanRequestParams) ([]T, error) {
+		endpoint := c.buildEndpoint(action, params)
 
-	var response models.EtherscanResponse
-	response.Result = &[]models.ERC1155Transfer{}
+		result := []T{}
+		err := c.makeRequest(ctx, endpoint, c.requestAPIKey(params), &result)
+		progressFrom(ctx).recordPage(action, len(result), err)
+		if err != nil {
+			return nil, err
+		}
 
-	if err := c.makeRequest(endpoint, &response); err != nil {
-		return nil, err
+		return result, nil
 	}
 
-	result, ok := response.Result.(*[]models.ERC1155Transfer)
-	if !ok {
-		return nil, fmt.Errorf("failed to parse token transfers response")
+	if params.Exhaustive {
+		return fetchAllPages(ctx, fetchPage, params)
 	}
-
-	return *result, nil
+	return fetchPage(ctx, params)
 }
 
 // buildEndpoint constructs an Etherscan API endpoint with the provided parameters
 func (c *Client) buildEndpoint(action string, params EtherscanRequestParams) string {
 	url := fmt.Sprintf("%s?chainid=%d&module=account&action=%s&address=%s",
-		c.baseURL, params.ChainId,action, params.Address)
+		c.chainURL(params.ChainId), params.ChainId,action, params.Address)
 
 	// Add optional contract address if specified (for token transfers)
 	if params.ContractAddress != "" {
@@ -151,11 +157,10 @@ func (c *Client) buildEndpoint(action string, params EtherscanRequestParams) str
 	}
 	url += fmt.Sprintf("&startblock=%d", startBlock)
 
-	endBlock := params.EndBlock
-	if endBlock < 0 {
-		endBlock = 99999999 // Default high value
+	// An open range runs to the latest block, which is the API's default
+	if params.EndBlock >= 0 {
+		url += fmt.Sprintf("&endblock=%d", params.EndBlock)
 	}
-	url += fmt.Sprintf("&endblock=%d", endBlock)
 
 	// Add pagination parameters if specified
 	if params.Page > 0 {
@@ -185,23 +190,95 @@ func (c *Client) buildEndpoint(action string, params EtherscanRequestParams) str
 	return url
 }
 
-// makeRequest makes an HTTP request to the Etherscan API
-func (c *Client) makeRequest(endpoint string, v interface{}) error {
+// requestAPIKey returns the API key a request with params is made with
+func (c *Client) requestAPIKey(params EtherscanRequestParams) string {
+	if params.ApiKey != "" {
+		return params.ApiKey
+	}
+	return c.apiKey
+}
+
+// makeRequest makes a rate limited request to the Etherscan API with the given API key,
+// retrying with exponential backoff while the failure is retryable and ctx is not done
+func (c *Client) makeRequest(ctx context.Context, endpoint, apiKey string, result interface{}) error {
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(ctx, apiKey); err != nil {
+			return err
+		}
+
+		err := c.doRequest(ctx, endpoint, result)
+		if err == nil || ctx.Err() != nil || attempt >= c.options.MaxRetries || !isRetryable(err) {
+			return err
+		}
+
+		if err := sleepContext(ctx, backoff(attempt, c.options.RetryBaseDelay, c.options.RetryMaxDelay)); err != nil {
+			return err
+		}
+	}
+}
 
-	resp, err := c.httpClient.Get(endpoint)
+// doRequest makes an HTTP request to the Etherscan API and decodes the result
+// field of the response envelope into result. Error envelopes and unexpected HTTP
+// responses are reported as *APIError.
+func (c *Client) doRequest(ctx context.Context, endpoint string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return fmt.Errorf("error making request: %w", err)
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// The caller gave up, which says nothing about the upstream
+		if ctx.Err() != nil {
+			return fmt.Errorf("error making request: %w", ctx.Err())
+		}
+		return fmt.Errorf("%w: error making request: %w", ErrUpstream, redactURLError(err))
 	}
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error reading response body: %w", err)
+		return fmt.Errorf("%w: error reading response body: %w", ErrUpstream, err)
 	}
 
-	if err := json.Unmarshal(body, v); err != nil {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &APIError{Kind: ErrRateLimited, StatusCode: resp.StatusCode, Message: resp.Status}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return &APIError{Kind: ErrUpstream, StatusCode: resp.StatusCode, Message: resp.Status}
+	}
+
+	var response models.EtherscanResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return &APIError{Kind: ErrUpstream, StatusCode: resp.StatusCode, Message: "malformed response: " + err.Error()}
+	}
+
+	// Status "0" marks an error envelope whose result describes the error
+	if response.Status == "0" {
+		var detail string
+		if err := json.Unmarshal(response.Result, &detail); err != nil {
+			detail = ""
+		}
+		return &APIError{
+			Kind:       classifyEnvelope(response.Message, detail),
+			StatusCode: resp.StatusCode,
+			Message:    response.Message,
+			Result:     detail,
+		}
+	}
+
+	if err := json.Unmarshal(response.Result, result); err != nil {
 		return fmt.Errorf("error unmarshaling response: %w", err)
 	}
 
 	return nil
 }
+
+// redactURLError strips the request URL, which carries the API key, from HTTP client errors
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
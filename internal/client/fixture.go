@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// blockItem is implemented by every transaction type
+type blockItem interface {
+	Block() int
+}
+
+// FixtureSource serves transactions from JSON files on disk, laid out as
+// <dir>/<chainid>/<address>/<action>.json where action is the Etherscan action name
+// (txlist, txlistinternal, tokentx, tokennfttx, token1155tx). A file holds either
+// a plain array of transactions or a full Etherscan response.
+type FixtureSource struct {
+	dir string
+}
+
+// NewFixtureSource creates a source reading fixtures from dir
+func NewFixtureSource(dir string) *FixtureSource {
+	return &FixtureSource{dir: dir}
+}
+
+// GetNormalTransactions reads normal transactions for the given address
+func (f *FixtureSource) GetNormalTransactions(ctx context.Context, params EtherscanRequestParams) ([]models.NormalTx, error) {
+	return loadFixture[models.NormalTx](ctx, f.dir, "txlist", params)
+}
+
+// GetInternalTransactions reads internal transactions for the given address
+func (f *FixtureSource) GetInternalTransactions(ctx context.Context, params EtherscanRequestParams) ([]models.InternalTx, error) {
+	return loadFixture[models.InternalTx](ctx, f.dir, "txlistinternal", params)
+}
+
+// GetERC20Transfers reads ERC-20 token transfers for the given address
+func (f *FixtureSource) GetERC20Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC20Transfer, error) {
+	return loadFixture[models.ERC20Transfer](ctx, f.dir, "tokentx", params)
+}
+
+// GetERC721Transfers reads ERC-721 token transfers for the given address
+func (f *FixtureSource) GetERC721Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC721Transfer, error) {
+	return loadFixture[models.ERC721Transfer](ctx, f.dir, "tokennfttx", params)
+}
+
+// GetERC1155Transfers reads ERC-1155 token transfers for the given address
+func (f *FixtureSource) GetERC1155Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC1155Transfer, error) {
+	return loadFixture[models.ERC1155Transfer](ctx, f.dir, "token1155tx", params)
+}
+
+// loadFixture reads a fixture file and applies the block range, sort order and pagination
+// of params the same way Etherscan would. A missing file means no transactions.
+func loadFixture[T blockItem](ctx context.Context, dir, action string, params EtherscanRequestParams) ([]T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, strconv.Itoa(params.ChainId), strings.ToLower(params.Address), action+".json")
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []T{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading fixture: %w", err)
+	}
+
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		// Fall back to a full Etherscan response
+		var response struct {
+			Result []T `json:"result"`
+		}
+		if err := json.Unmarshal(data, &response); err != nil {
+			return nil, fmt.Errorf("error unmarshaling fixture %s: %w", path, err)
+		}
+		items = response.Result
+	}
+
+	return applyRequestParams(items, params), nil
+}
+
+// applyRequestParams filters items to the requested block range, sorts them by block
+// and returns the requested page, or every item in exhaustive mode
+func applyRequestParams[T blockItem](items []T, params EtherscanRequestParams) []T {
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		block := int64(item.Block())
+		if block < params.StartBlock {
+			continue
+		}
+		if params.EndBlock >= 0 && block > params.EndBlock {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if params.Sort == "desc" {
+			return filtered[i].Block() > filtered[j].Block()
+		}
+		return filtered[i].Block() < filtered[j].Block()
+	})
+
+	if params.Offset > 0 && !params.Exhaustive {
+		page := params.Page
+		if page <= 0 {
+			page = 1
+		}
+
+		start := (page - 1) * params.Offset
+		if start >= len(filtered) {
+			return []T{}
+		}
+		end := start + params.Offset
+		if end > len(filtered) {
+			end = len(filtered)
+		}
+		filtered = filtered[start:end]
+	}
+
+	return filtered
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	// MaxResultWindow is the maximum number of records Etherscan returns for one
+	// query: page x offset must not exceed it
+	MaxResultWindow = 10000
+
+	// exhaustivePageSize is the page size used when walking a block range
+	exhaustivePageSize = 1000
+)
+
+// ErrTruncated is returned together with the transactions that could be retrieved
+// when a single block holds more records than the result window
+var ErrTruncated = errors.New("result window exceeded, results truncated")
+
+// PageFetcher fetches a single page of transactions
+type PageFetcher[T any] func(ctx context.Context, params EtherscanRequestParams) ([]T, error)
+
+// WalkFunc receives a batch of a block range walk. Every item up to block through has been
+// walked once the batch is handled, through being -1 at the end of an open range.
+// truncated reports that the batch misses items of a block exceeding the result window.
+type WalkFunc[T any] func(batch []T, through int64, truncated bool) error
+
+// WalkBlockRange retrieves every transaction in the block range of params in ascending
+// block order, calling fn with each batch. Pages are walked until the result window is
+// full, at which point the range is split at the last block seen: the blocks before it
+// are complete, and the remaining range is walked again starting at that block.
+// It reports whether any block had to be truncated because it alone exceeds the window.
+func WalkBlockRange[T blockItem](ctx context.Context, fetch PageFetcher[T], params EtherscanRequestParams, fn WalkFunc[T]) (bool, error) {
+	params.Offset = exhaustivePageSize
+	params.Sort = "asc"
+	params.Exhaustive = false
+	if params.StartBlock < 0 {
+		params.StartBlock = 0
+	}
+
+	truncated := false
+	pages := MaxResultWindow / exhaustivePageSize
+
+	for {
+		var window []T
+		full := false
+
+		for page := 1; page <= pages; page++ {
+			params.Page = page
+
+			batch, err := fetch(ctx, params)
+			if errors.Is(err, ErrNoTransactions) {
+				batch, err = nil, nil
+			}
+			if err != nil {
+				return truncated, err
+			}
+
+			window = append(window, batch...)
+			if len(batch) < params.Offset {
+				break
+			}
+			full = page == pages
+		}
+
+		// The range fits in the window, so it is complete
+		if !full {
+			return truncated, fn(window, params.EndBlock, false)
+		}
+
+		last := int64(window[len(window)-1].Block())
+
+		// The whole window is a single block, which cannot be split any further
+		if last <= params.StartBlock {
+			truncated = true
+			if err := fn(window, last, true); err != nil {
+				return truncated, err
+			}
+			if params.EndBlock >= 0 && last >= params.EndBlock {
+				return truncated, nil
+			}
+			params.StartBlock = last + 1
+			continue
+		}
+
+		// Keep the complete blocks and walk the rest of the range from the last block
+		cut := len(window)
+		for cut > 0 && int64(window[cut-1].Block()) == last {
+			cut--
+		}
+		if err := fn(window[:cut], last-1, false); err != nil {
+			return truncated, err
+		}
+		params.StartBlock = last
+	}
+}
+
+// fetchAllPages retrieves every transaction in the block range of params,
+// returning ErrTruncated along with the results if some could not be retrieved,
+// and ErrNoTransactions if the range holds none, as a single page request does
+func fetchAllPages[T blockItem](ctx context.Context, fetch PageFetcher[T], params EtherscanRequestParams) ([]T, error) {
+	all := []T{}
+	truncated, err := WalkBlockRange(ctx, fetch, params, func(batch []T, _ int64, _ bool) error {
+		all = append(all, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(all) == 0 {
+		return all, ErrNoTransactions
+	}
+
+	// Results are walked in ascending order
+	if params.Sort == "desc" {
+		for i, j := 0, len(all)-1; i < j; i, j = i+1, j-1 {
+			all[i], all[j] = all[j], all[i]
+		}
+	}
+
+	if truncated {
+		return all, ErrTruncated
+	}
+	return all, nil
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// testItem is a transaction of the fake page fetcher, identified by its position
+type testItem struct {
+	block int
+	id    int
+}
+
+func (t testItem) Block() int { return t.block }
+
+// blocks creates count items in each of the given blocks, in ascending block order
+func blocks(count int, blockNumbers ...int) []testItem {
+	var items []testItem
+	for _, block := range blockNumbers {
+		for range count {
+			items = append(items, testItem{block: block})
+		}
+	}
+	return items
+}
+
+// blockRange returns the block numbers from first to last
+func blockRange(first, last int) []int {
+	var numbers []int
+	for block := first; block <= last; block++ {
+		numbers = append(numbers, block)
+	}
+	return numbers
+}
+
+// fakeFetcher serves items the way Etherscan does: filtered by block range, sorted,
+// paginated, rejecting pages beyond the result window and reporting no results as
+// ErrNoTransactions. It records the params of every request.
+func fakeFetcher(items []testItem, requests *[]EtherscanRequestParams) PageFetcher[testItem] {
+	for i := range items {
+		items[i].id = i
+	}
+
+	return func(ctx context.Context, params EtherscanRequestParams) ([]testItem, error) {
+		*requests = append(*requests, params)
+		if params.Page*params.Offset > MaxResultWindow {
+			return nil, errors.New("result window is too large")
+		}
+
+		var matching []testItem
+		for _, item := range items {
+			if int64(item.block) >= params.StartBlock && (params.EndBlock < 0 || int64(item.block) <= params.EndBlock) {
+				matching = append(matching, item)
+			}
+		}
+		if params.Sort == "desc" {
+			slices.Reverse(matching)
+		}
+
+		start := (params.Page - 1) * params.Offset
+		if start >= len(matching) {
+			return nil, ErrNoTransactions
+		}
+		return matching[start:min(start+params.Offset, len(matching))], nil
+	}
+}
+
+func TestFetchAllPages(t *testing.T) {
+	tests := []struct {
+		name         string
+		items        []testItem
+		startBlock   int64
+		endBlock     int64
+		sort         string
+		wantIds      []int // Ids of the expected items in order, all items ascending if nil
+		wantErr      error
+		wantRequests int
+	}{
+		{
+			name:         "fits in one window",
+			items:        blocks(3, blockRange(100, 199)...),
+			endBlock:     -1,
+			sort:         "asc",
+			wantRequests: 1,
+		},
+		{
+			// The window is split before its last block, which is fetched again on its own
+			name:         "exactly full window",
+			items:        blocks(2, blockRange(0, 4999)...),
+			endBlock:     -1,
+			sort:         "asc",
+			wantRequests: 11,
+		},
+		{
+			name:         "exactly full window at the end of a closed range",
+			items:        blocks(2, blockRange(0, 5999)...),
+			endBlock:     4999,
+			sort:         "asc",
+			wantIds:      blockRange(0, 9999),
+			wantRequests: 11,
+		},
+		{
+			name:         "several windows",
+			items:        blocks(5, blockRange(1000, 5999)...),
+			startBlock:   1000,
+			endBlock:     -1,
+			sort:         "asc",
+			wantRequests: 26,
+		},
+		{
+			// The first window only reaches into block 7, the second one is all of it and the
+			// rest of the range is walked from block 8
+			name:         "single block larger than the window",
+			items:        append(blocks(12000, 7), blocks(10, 8, 9)...),
+			endBlock:     -1,
+			sort:         "asc",
+			wantIds:      append(blockRange(0, 9999), blockRange(12000, 12019)...),
+			wantErr:      ErrTruncated,
+			wantRequests: 21,
+		},
+		{
+			name:         "single block larger than the window at the end of the range",
+			items:        append(blocks(5, 6), blocks(12000, 7)...),
+			endBlock:     7,
+			sort:         "asc",
+			wantIds:      append(blockRange(0, 4), blockRange(5, 10004)...),
+			wantErr:      ErrTruncated,
+			wantRequests: 20,
+		},
+		{
+			name:         "desc re-sort",
+			items:        blocks(3, blockRange(0, 3999)...),
+			endBlock:     -1,
+			sort:         "desc",
+			wantRequests: 13,
+		},
+		{
+			name:         "no transactions",
+			endBlock:     -1,
+			sort:         "asc",
+			wantIds:      []int{},
+			wantErr:      ErrNoTransactions,
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []EtherscanRequestParams
+			fetch := fakeFetcher(tt.items, &requests)
+
+			params := EtherscanRequestParams{StartBlock: tt.startBlock, EndBlock: tt.endBlock, Sort: tt.sort, Exhaustive: true}
+			got, err := fetchAllPages(context.Background(), fetch, params)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("fetchAllPages() error = %v, want %v", err, tt.wantErr)
+			}
+
+			wantIds := tt.wantIds
+			if wantIds == nil {
+				wantIds = blockRange(0, len(tt.items)-1)
+			}
+			if tt.sort == "desc" {
+				wantIds = slices.Clone(wantIds)
+				slices.Reverse(wantIds)
+			}
+			gotIds := make([]int, len(got))
+			for i, item := range got {
+				gotIds[i] = item.id
+			}
+			if !slices.Equal(gotIds, wantIds) {
+				t.Errorf("fetchAllPages() returned %d items %s, want %d items %s", len(gotIds), span(gotIds), len(wantIds), span(wantIds))
+			}
+
+			if len(requests) != tt.wantRequests {
+				t.Errorf("fetchAllPages() made %d requests, want %d", len(requests), tt.wantRequests)
+			}
+			for _, request := range requests {
+				if request.Sort != "asc" || request.Exhaustive || request.Offset != exhaustivePageSize {
+					t.Fatalf("request %+v, want ascending pages of %d", request, exhaustivePageSize)
+				}
+			}
+		})
+	}
+}
+
+// span summarizes ids by their first and last elements
+func span(ids []int) string {
+	if len(ids) == 0 {
+		return "[]"
+	}
+	return fmt.Sprintf("[%d … %d]", ids[0], ids[len(ids)-1])
+}
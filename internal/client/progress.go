@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// actionTypes maps the Etherscan actions to the transfer types they list
+var actionTypes = map[string]string{
+	"txlist":         models.TransferNormal,
+	"txlistinternal": models.TransferInternal,
+	"tokentx":        models.TransferERC20,
+	"tokennfttx":     models.TransferERC721,
+	"token1155tx":    models.TransferERC1155,
+}
+
+// Progress counts the pages and records fetched from the upstream API per transfer type
+// while serving one analysis. It is safe for concurrent use.
+type Progress struct {
+	mu    sync.Mutex
+	types map[string]models.ListProgress
+}
+
+// progressKey is the context key of the analysis' Progress
+type progressKey struct{}
+
+// WithProgress returns a context carrying the Progress that clients record fetched pages into
+func WithProgress(ctx context.Context, progress *Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, progress)
+}
+
+// progressFrom returns the Progress carried by ctx, or nil
+func progressFrom(ctx context.Context) *Progress {
+	progress, _ := ctx.Value(progressKey{}).(*Progress)
+	return progress
+}
+
+// recordPage counts a page of the given action holding records, if the fetch succeeded
+func (p *Progress) recordPage(action string, records int, err error) {
+	if p == nil || (err != nil && !errors.Is(err, ErrNoTransactions)) {
+		return
+	}
+
+	name, ok := actionTypes[action]
+	if !ok {
+		name = action
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.types == nil {
+		p.types = map[string]models.ListProgress{}
+	}
+	list := p.types[name]
+	list.Pages++
+	list.Records += records
+	p.types[name] = list
+}
+
+// Snapshot returns the pages and records fetched so far per transfer type
+func (p *Progress) Snapshot() map[string]models.ListProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	snapshot := make(map[string]models.ListProgress, len(p.types))
+	for name, list := range p.types {
+		snapshot[name] = list
+	}
+	return snapshot
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// ErrUnknownSource is returned when a transaction source is not registered
+var ErrUnknownSource = errors.New("unknown transaction source")
+
+// TransactionSource fetches the transaction lists of an address from a data backend
+type TransactionSource interface {
+	GetNormalTransactions(ctx context.Context, params EtherscanRequestParams) ([]models.NormalTx, error)
+	GetInternalTransactions(ctx context.Context, params EtherscanRequestParams) ([]models.InternalTx, error)
+	GetERC20Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC20Transfer, error)
+	GetERC721Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC721Transfer, error)
+	GetERC1155Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC1155Transfer, error)
+}
+
+// Ensure the Etherscan client is a transaction source
+var _ TransactionSource = (*Client)(nil)
+
+// SourceRegistry holds the configured transaction sources by name
+type SourceRegistry struct {
+	sources     map[string]TransactionSource
+	defaultName string
+}
+
+// NewSourceRegistry creates an empty registry whose default source is defaultName
+func NewSourceRegistry(defaultName string) *SourceRegistry {
+	return &SourceRegistry{
+		sources:     map[string]TransactionSource{},
+		defaultName: defaultName,
+	}
+}
+
+// Register adds a source under the given name, replacing any previous one
+func (r *SourceRegistry) Register(name string, source TransactionSource) {
+	r.sources[name] = source
+}
+
+// Get returns the source registered under name, or the default source if name is empty
+func (r *SourceRegistry) Get(name string) (TransactionSource, error) {
+	if name == "" {
+		name = r.defaultName
+	}
+
+	source, ok := r.sources[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSource, name)
+	}
+
+	return source, nil
+}
+
+// Names returns the names of all registered sources in alphabetical order
+func (r *SourceRegistry) Names() []string {
+	names := make([]string, 0, len(r.sources))
+	for name := range r.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
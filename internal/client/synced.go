@@ -0,0 +1,224 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/store"
+)
+
+// syncSaveInterval is how often the progress of a long sync is saved
+const syncSaveInterval = 10 * time.Second
+
+// SyncedSource answers requests from a local store of each address's transaction history.
+// The store is synced up to the finalized chain head, or the end of the requested range if
+// it is older, on every request, fetching only the blocks after the last synced one; newer
+// blocks are fetched from the wrapped source as is.
+// If the wrapped source fails, the stored history is served and reported as truncated.
+type SyncedSource struct {
+	name          string // Distinguishes sources sharing a store
+	inner         TransactionSource
+	store         *store.Store
+	heads         *chainHeads
+	finalityDepth int64
+}
+
+// NewSyncedSource wraps the source registered as name with the store. Blocks at least
+// finalityDepth blocks behind the chain head are considered final and are stored.
+func NewSyncedSource(name string, inner TransactionSource, st *store.Store, finalityDepth int64) *SyncedSource {
+	return &SyncedSource{
+		name:          name,
+		inner:         inner,
+		store:         st,
+		heads:         newChainHeads(inner),
+		finalityDepth: finalityDepth,
+	}
+}
+
+// GetNormalTransactions fetches normal transactions through the store
+func (s *SyncedSource) GetNormalTransactions(ctx context.Context, params EtherscanRequestParams) ([]models.NormalTx, error) {
+	return syncedFetch(ctx, s, "txlist", params, s.inner.GetNormalTransactions)
+}
+
+// GetInternalTransactions fetches internal transactions through the store
+func (s *SyncedSource) GetInternalTransactions(ctx context.Context, params EtherscanRequestParams) ([]models.InternalTx, error) {
+	return syncedFetch(ctx, s, "txlistinternal", params, s.inner.GetInternalTransactions)
+}
+
+// GetERC20Transfers fetches ERC-20 token transfers through the store
+func (s *SyncedSource) GetERC20Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC20Transfer, error) {
+	return syncedFetch(ctx, s, "tokentx", params, s.inner.GetERC20Transfers)
+}
+
+// GetERC721Transfers fetches ERC-721 token transfers through the store
+func (s *SyncedSource) GetERC721Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC721Transfer, error) {
+	return syncedFetch(ctx, s, "tokennfttx", params, s.inner.GetERC721Transfers)
+}
+
+// GetERC1155Transfers fetches ERC-1155 token transfers through the store
+func (s *SyncedSource) GetERC1155Transfers(ctx context.Context, params EtherscanRequestParams) ([]models.ERC1155Transfer, error) {
+	return syncedFetch(ctx, s, "token1155tx", params, s.inner.GetERC1155Transfers)
+}
+
+// LatestBlock fetches the chain head from the wrapped source
+func (s *SyncedSource) LatestBlock(ctx context.Context, chainId int, apiKey string) (int64, error) {
+	return s.heads.latest(ctx, chainId, apiKey)
+}
+
+// syncedFetch syncs the stored list of the requested address, then applies the block range,
+// sort order and pagination of params to the stored items and the unfinalized blocks
+func syncedFetch[T blockItem](
+	ctx context.Context,
+	s *SyncedSource,
+	action string,
+	params EtherscanRequestParams,
+	fetch PageFetcher[T],
+) ([]T, error) {
+	unlock := s.store.Lock(s.name, params.ChainId, params.Address, action)
+	defer unlock()
+
+	record, err := store.Load[T](s.store, s.name, params.ChainId, params.Address, action)
+	if err != nil {
+		return nil, err
+	}
+
+	items, truncated, err := syncRecord(ctx, s, &record, action, params, fetch)
+	if err != nil {
+		// Serve the stored history when the source is degraded
+		if ctx.Err() != nil || record.SyncedBlock < 0 {
+			return nil, err
+		}
+		items, truncated = record.Items, true
+	}
+
+	items = applyRequestParams(items, params)
+	switch {
+	case truncated:
+		return items, ErrTruncated
+	case len(items) == 0:
+		return items, ErrNoTransactions
+	}
+	return items, nil
+}
+
+// syncRecord fetches the finalized blocks after the last synced one up to the end of the
+// requested range into the store, then the unfinalized blocks of the range. It returns the
+// stored and unfinalized items, and whether a block of the range was truncated.
+func syncRecord[T blockItem](
+	ctx context.Context,
+	s *SyncedSource,
+	record *store.Record[T],
+	action string,
+	params EtherscanRequestParams,
+	fetch PageFetcher[T],
+) ([]T, bool, error) {
+	head, err := s.heads.latest(ctx, params.ChainId, params.ApiKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("error fetching the latest block: %w", err)
+	}
+
+	final := head - s.finalityDepth
+	if params.EndBlock >= 0 {
+		final = min(final, params.EndBlock)
+	}
+	if final > record.SyncedBlock {
+		if err := syncFinalized(ctx, s, record, action, params, fetch, final); err != nil {
+			return nil, false, err
+		}
+	}
+
+	items := record.Items
+	truncated := slices.ContainsFunc(record.TruncatedBlocks, func(block int64) bool {
+		return block >= params.StartBlock && (params.EndBlock < 0 || block <= params.EndBlock)
+	})
+
+	// Unfinalized blocks may still be reorganized, so they are fetched but not stored
+	start := max(record.SyncedBlock+1, params.StartBlock)
+	if params.EndBlock < 0 || params.EndBlock >= start {
+		recent, recentTruncated, err := walk(ctx, fetch, params, start, params.EndBlock)
+		if err != nil {
+			return nil, false, err
+		}
+		items = append(slices.Clip(items), recent...)
+		truncated = truncated || recentTruncated
+	}
+
+	return items, truncated, nil
+}
+
+// syncFinalized walks the blocks after the last synced one up to final into the record. Progress is
+// saved every syncSaveInterval and when the walk ends, failed or not, so an interrupted sync resumes
+// where it stopped. A walk finding nothing new only updates the record kept in memory.
+func syncFinalized[T blockItem](
+	ctx context.Context,
+	s *SyncedSource,
+	record *store.Record[T],
+	action string,
+	params EtherscanRequestParams,
+	fetch PageFetcher[T],
+	final int64,
+) error {
+	walkParams := EtherscanRequestParams{
+		Address:    params.Address,
+		ChainId:    params.ChainId,
+		ApiKey:     params.ApiKey,
+		StartBlock: record.SyncedBlock + 1,
+		EndBlock:   final,
+	}
+
+	changed := false // Items were added since the last save
+	lastSave := time.Now()
+	save := func() error {
+		record.Updated = time.Now()
+		if !changed {
+			store.Remember(s.store, s.name, params.ChainId, params.Address, action, *record)
+			return nil
+		}
+		changed, lastSave = false, time.Now()
+		return store.Save(s.store, s.name, params.ChainId, params.Address, action, *record)
+	}
+
+	_, err := WalkBlockRange(ctx, fetch, walkParams, func(batch []T, through int64, truncated bool) error {
+		record.Items = append(record.Items, batch...)
+		record.SyncedBlock = through
+		if truncated {
+			record.TruncatedBlocks = append(record.TruncatedBlocks, through)
+		}
+		changed = changed || len(batch) > 0 || truncated
+
+		if changed && time.Since(lastSave) >= syncSaveInterval {
+			return save()
+		}
+		return nil
+	})
+	if err != nil {
+		// Keep the blocks synced before the failure
+		save()
+		return fmt.Errorf("error syncing blocks %d to %d: %w", walkParams.StartBlock, final, err)
+	}
+	return save()
+}
+
+// walk retrieves every item between two blocks
+func walk[T blockItem](ctx context.Context, fetch PageFetcher[T], params EtherscanRequestParams, startBlock, endBlock int64) ([]T, bool, error) {
+	walkParams := EtherscanRequestParams{
+		Address:    params.Address,
+		ChainId:    params.ChainId,
+		ApiKey:     params.ApiKey,
+		StartBlock: startBlock,
+		EndBlock:   endBlock,
+	}
+
+	items := []T{}
+	truncated, err := WalkBlockRange(ctx, fetch, walkParams, func(batch []T, _ int64, _ bool) error {
+		items = append(items, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("error syncing blocks %d to %d: %w", startBlock, endBlock, err)
+	}
+	return items, truncated, nil
+}
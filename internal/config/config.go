@@ -2,12 +2,67 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	EtherscanAPIKey  string
 	EtherscanBaseURL string
+
+	// Etherscan client behaviour
+	EtherscanTimeout        time.Duration // Timeout of a single request
+	EtherscanRateLimit      float64       // Requests per second per API key, 0 disables limiting
+	EtherscanRateBurst      int           // Requests allowed in a burst per API key
+	EtherscanMaxRetries     int           // Retries after rate limit responses, 5xx responses and timeouts
+	EtherscanRetryBaseDelay time.Duration // Delay before the first retry
+	EtherscanRetryMaxDelay  time.Duration // Upper bound of the retry delay
+
+	RequestTimeout time.Duration // Default deadline of an analysis request, 0 for none
+
+	// Response cache
+	CacheMaxMB         int           // Memory budget of the cache in megabytes, 0 disables caching
+	CacheDir           string        // Directory of the on-disk cache, empty for memory only
+	CacheTTL           time.Duration // Lifetime of entries whose block range may still change
+	CacheFinalizedTTL  time.Duration // Lifetime of entries whose block range is finalized
+	CacheFinalityDepth int           // Blocks behind the chain head after which a block is final
+
+	// Local transaction store
+	StoreDir           string // Directory of the transaction store, empty to disable it
+	StoreFinalityDepth int    // Blocks behind the chain head after which blocks are stored
+
+	// Background jobs
+	JobWorkers   int           // Jobs run concurrently
+	JobQueueSize int           // Jobs waiting for a worker before submissions are refused
+	JobTimeout   time.Duration // Default deadline of a job, 0 for none
+	JobRetention time.Duration // How long finished jobs can be polled
+
+	// Batch analysis
+	BatchConcurrency  int // Addresses of a batch analyzed concurrently
+	BatchMaxAddresses int // Addresses accepted in one batch
+
+	// Cross-chain analysis
+	ChainConcurrency int // Chains of a cross-chain analysis fetched concurrently
+
+	// Address labels
+	LabelsFile   string   // JSON file holding the label registry, empty to keep labels in memory
+	LabelsImport []string // CSV and JSON label files, or directories of them, imported at startup
+
+	// Sanctions screening
+	SanctionsLists         []string      // Sanctions list files, each optionally preceded by its name and "="
+	SanctionsCheckInterval time.Duration // How often list files are checked for changes, 0 to only reload on request
+
+	// Chains
+	ChainsFile string // JSON file of chains merged into the built-in chain registry, empty for none
+
+	// Transaction sources
+	TxSource          string // Name of the default transaction source
+	BlockscoutBaseURL string // Etherscan-compatible Blockscout API, registered as "blockscout" when set
+	BlockscoutAPIKey  string
+	FixtureDir        string // Directory of fixture files, registered as "fixture" when set
 }
 
 func Load() (*Config, error) {
@@ -21,8 +76,145 @@ func Load() (*Config, error) {
 		baseURL = "https://api.etherscan.io/v2/api" // Default API
 	}
 
-	return &Config{
-		EtherscanAPIKey:  apiKey,
-		EtherscanBaseURL: baseURL,
-	}, nil
+	txSource := os.Getenv("TX_SOURCE")
+	if txSource == "" {
+		txSource = "etherscan" // Default transaction source
+	}
+
+	cfg := &Config{
+		EtherscanAPIKey:   apiKey,
+		EtherscanBaseURL:  baseURL,
+		TxSource:          txSource,
+		BlockscoutBaseURL: os.Getenv("BLOCKSCOUT_BASE_URL"),
+		BlockscoutAPIKey:  os.Getenv("BLOCKSCOUT_API_KEY"),
+		FixtureDir:        os.Getenv("FIXTURE_DIR"),
+		CacheDir:          os.Getenv("CACHE_DIR"),
+		StoreDir:          os.Getenv("STORE_DIR"),
+		ChainsFile:        os.Getenv("CHAINS_FILE"),
+		LabelsFile:        os.Getenv("LABELS_FILE"),
+		LabelsImport:      listEnv("LABELS_IMPORT"),
+		SanctionsLists:    listEnv("SANCTIONS_LISTS"),
+	}
+
+	var err error
+	if cfg.EtherscanTimeout, err = durationEnv("ETHERSCAN_TIMEOUT", 15*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.EtherscanRateLimit, err = floatEnv("ETHERSCAN_RATE_LIMIT", 5); err != nil {
+		return nil, err
+	}
+	if cfg.EtherscanRateBurst, err = intEnv("ETHERSCAN_RATE_BURST", 5); err != nil {
+		return nil, err
+	}
+	if cfg.EtherscanMaxRetries, err = intEnv("ETHERSCAN_MAX_RETRIES", 3); err != nil {
+		return nil, err
+	}
+	if cfg.EtherscanRetryBaseDelay, err = durationEnv("ETHERSCAN_RETRY_BASE_DELAY", 500*time.Millisecond); err != nil {
+		return nil, err
+	}
+	if cfg.EtherscanRetryMaxDelay, err = durationEnv("ETHERSCAN_RETRY_MAX_DELAY", 8*time.Second); err != nil {
+		return nil, err
+	}
+
+	if cfg.RequestTimeout, err = durationEnv("REQUEST_TIMEOUT", 60*time.Second); err != nil {
+		return nil, err
+	}
+
+	if cfg.JobWorkers, err = intEnv("JOB_WORKERS", 4); err != nil {
+		return nil, err
+	}
+	if cfg.JobQueueSize, err = intEnv("JOB_QUEUE_SIZE", 100); err != nil {
+		return nil, err
+	}
+	if cfg.JobTimeout, err = durationEnv("JOB_TIMEOUT", 30*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.JobRetention, err = durationEnv("JOB_RETENTION", time.Hour); err != nil {
+		return nil, err
+	}
+
+	if cfg.BatchConcurrency, err = intEnv("BATCH_CONCURRENCY", 4); err != nil {
+		return nil, err
+	}
+	if cfg.BatchMaxAddresses, err = intEnv("BATCH_MAX_ADDRESSES", 500); err != nil {
+		return nil, err
+	}
+	if cfg.ChainConcurrency, err = intEnv("CHAIN_CONCURRENCY", 4); err != nil {
+		return nil, err
+	}
+
+	if cfg.SanctionsCheckInterval, err = durationEnv("SANCTIONS_CHECK_INTERVAL", time.Minute); err != nil {
+		return nil, err
+	}
+
+	if cfg.CacheMaxMB, err = intEnv("CACHE_MAX_MB", 256); err != nil {
+		return nil, err
+	}
+	if cfg.CacheTTL, err = durationEnv("CACHE_TTL", time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.CacheFinalizedTTL, err = durationEnv("CACHE_FINALIZED_TTL", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.CacheFinalityDepth, err = intEnv("CACHE_FINALITY_DEPTH", 64); err != nil {
+		return nil, err
+	}
+	if cfg.StoreFinalityDepth, err = intEnv("STORE_FINALITY_DEPTH", 64); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// listEnv reads a comma-separated environment variable, returning nil if it is unset
+func listEnv(name string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// intEnv reads an integer environment variable, returning def if it is unset
+func intEnv(name string, def int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return i, nil
+}
+
+// floatEnv reads a float environment variable, returning def if it is unset
+func floatEnv(name string, def float64) (float64, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return f, nil
+}
+
+// durationEnv reads a duration environment variable such as "500ms", returning def if it is unset
+func durationEnv(name string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return d, nil
 }
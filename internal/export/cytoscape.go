@@ -0,0 +1,60 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// cytoscapeDocument is a Cytoscape.js elements document, as accepted by cy.json()
+type cytoscapeDocument struct {
+	Elements cytoscapeElements `json:"elements"`
+}
+
+type cytoscapeElements struct {
+	Nodes []cytoscapeElement `json:"nodes"`
+	Edges []cytoscapeElement `json:"edges"`
+}
+
+type cytoscapeElement struct {
+	Data map[string]any `json:"data"`
+}
+
+// writeCytoscape renders the graph as Cytoscape.js JSON
+func writeCytoscape(w io.Writer, g graph) error {
+	doc := cytoscapeDocument{
+		Elements: cytoscapeElements{
+			Nodes: make([]cytoscapeElement, 0, len(g.nodes)),
+			Edges: make([]cytoscapeElement, 0, len(g.edges)),
+		},
+	}
+
+	for _, n := range g.nodes {
+		data := cytoscapeData(nodeAttributes, n.values)
+		data["id"] = n.id
+		doc.Elements.Nodes = append(doc.Elements.Nodes, cytoscapeElement{Data: data})
+	}
+
+	for _, e := range g.edges {
+		data := cytoscapeData(edgeAttributes, e.values)
+		data["id"] = e.id
+		data["source"] = e.source
+		data["target"] = e.target
+		data["label"] = e.label
+		doc.Elements.Edges = append(doc.Elements.Edges, cytoscapeElement{Data: data})
+	}
+
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		return fmt.Errorf("error encoding Cytoscape JSON: %w", err)
+	}
+	return nil
+}
+
+// cytoscapeData maps attribute names to their typed values
+func cytoscapeData(attributes []attribute, values []any) map[string]any {
+	data := make(map[string]any, len(attributes)+4)
+	for i, a := range attributes {
+		data[a.name] = values[i]
+	}
+	return data
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dotEscaper escapes strings inside double-quoted DOT identifiers
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeDOT renders the graph in the Graphviz DOT language
+func writeDOT(w io.Writer, g graph) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "digraph flow {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [shape=box];")
+
+	for _, n := range g.nodes {
+		fmt.Fprintf(bw, "  %s [label=%s%s];\n", dotQuote(n.id), dotQuote(n.id), dotAttributes(nodeAttributes, n.values))
+	}
+
+	for _, e := range g.edges {
+		fmt.Fprintf(bw, "  %s -> %s [label=%s%s];\n", dotQuote(e.source), dotQuote(e.target), dotQuote(e.label), dotAttributes(edgeAttributes, e.values))
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotAttributes renders attribute values as a DOT attribute list suffix. The weight is
+// left out since Graphviz layouts read it as an integer edge weight.
+func dotAttributes(attributes []attribute, values []any) string {
+	var sb strings.Builder
+	for i, a := range attributes {
+		if a.name == "weight" {
+			continue
+		}
+		fmt.Fprintf(&sb, ", %s=%s", a.name, dotQuote(fmt.Sprint(values[i])))
+	}
+	return sb.String()
+}
+
+// dotQuote returns s as a double-quoted DOT identifier
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,216 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/utils"
+)
+
+// Graph export formats
+const (
+	FormatGraphML   = "graphml"
+	FormatDOT       = "dot"
+	FormatGEXF      = "gexf"
+	FormatCytoscape = "cytoscape"
+)
+
+// writers renders a flow graph in each export format
+var writers = map[string]struct {
+	contentType string
+	write       func(w io.Writer, g graph) error
+}{
+	FormatGraphML:   {"application/graphml+xml", writeGraphML},
+	FormatDOT:       {"text/vnd.graphviz", writeDOT},
+	FormatGEXF:      {"application/gexf+xml", writeGEXF},
+	FormatCytoscape: {"application/vnd.cytoscape+json", writeCytoscape},
+}
+
+// IsFormat reports whether format is a supported export format
+func IsFormat(format string) bool {
+	_, ok := writers[format]
+	return ok
+}
+
+// FormatForMediaType returns the export format served as the given media type
+func FormatForMediaType(mediaType string) (string, bool) {
+	for format, writer := range writers {
+		if writer.contentType == mediaType {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// ContentType returns the media type of an export format
+func ContentType(format string) string {
+	return writers[format].contentType
+}
+
+// Write renders the flow graph in the given format
+func Write(w io.Writer, format string, flow models.FlowGraph) error {
+	writer, ok := writers[format]
+	if !ok {
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+
+	// Render to a buffer so that a failure does not leave a partial document
+	var buf bytes.Buffer
+	if err := writer.write(&buf, newGraph(flow)); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// FromBeneficiaries builds the outflow graph from address to its beneficiaries
+func FromBeneficiaries(address string, beneficiaries []models.Beneficiary) models.FlowGraph {
+	flow := newStarGraph(address, "out")
+	for _, b := range beneficiaries {
+		addStarEdge(&flow, b.Address, b.Amount, b.Assets, b.Created, b.Transactions)
+	}
+	return flow
+}
+
+// FromPayers builds the inflow graph from the payers of address to address
+func FromPayers(address string, payers []models.Payer) models.FlowGraph {
+	flow := newStarGraph(address, "in")
+	for _, p := range payers {
+		addStarEdge(&flow, p.Address, p.Amount, p.Assets, p.Created, p.Transactions)
+	}
+	return flow
+}
+
+// newStarGraph creates a graph holding only the target address
+func newStarGraph(address, direction string) models.FlowGraph {
+	root := strings.ToLower(address)
+	return models.FlowGraph{
+		Root:      root,
+		Direction: direction,
+		Nodes:     []models.FlowNode{{Address: root, Depth: 0, Expanded: true}},
+		Edges:     []models.FlowEdge{},
+	}
+}
+
+// addStarEdge adds a counterparty of the target address and the edge between them
+func addStarEdge(flow *models.FlowGraph, counterparty string, amount utils.Amount, assets map[string]*models.AssetAmount, created bool, transactions []models.Transaction) {
+	// Transfers without a counterparty address cannot be drawn
+	if counterparty == "" {
+		return
+	}
+
+	// Self-transfers are drawn as a loop on the target address
+	counterparty = strings.ToLower(counterparty)
+	if counterparty != flow.Root {
+		flow.Nodes = append(flow.Nodes, models.FlowNode{Address: counterparty, Depth: 1})
+	}
+
+	edge := models.FlowEdge{
+		From:     flow.Root,
+		To:       counterparty,
+		Amount:   amount,
+		Assets:   assets,
+		TxHashes: make([]string, 0, len(transactions)),
+		Created:  created,
+	}
+	if flow.Direction == "in" {
+		edge.From, edge.To = counterparty, flow.Root
+	}
+	for _, tx := range transactions {
+		edge.TxHashes = append(edge.TxHashes, tx.TransactionID)
+	}
+	flow.Edges = append(flow.Edges, edge)
+}
+
+// attribute is a typed node or edge attribute: "string", "int", "double" or "boolean"
+type attribute struct {
+	name string
+	kind string
+}
+
+// nodeAttributes are the attributes of every node, in the order of node.values
+var nodeAttributes = []attribute{
+	{"address", "string"},
+	{"depth", "int"},
+	{"expanded", "boolean"},
+	{"root", "boolean"},
+}
+
+// edgeAttributes are the attributes of every edge, in the order of edge.values
+var edgeAttributes = []attribute{
+	{"asset", "string"},
+	{"symbol", "string"},
+	{"amount", "string"},
+	{"raw_amount", "string"},
+	{"decimals", "int"},
+	{"weight", "double"},
+	{"tx_count", "int"},
+	{"first_timestamp", "string"},
+	{"last_timestamp", "string"},
+	{"contract_creation", "boolean"},
+}
+
+// node is a graph node with its attribute values
+type node struct {
+	id     string
+	values []any
+}
+
+// edge is a directed edge carrying the amount of a single asset, with its attribute values
+type edge struct {
+	id     string
+	source string
+	target string
+	label  string
+	weight float64
+	values []any
+}
+
+// graph is a flow graph flattened for rendering, with one edge per pair of
+// addresses and asset
+type graph struct {
+	nodes []node
+	edges []edge
+}
+
+// newGraph flattens a flow graph, splitting every edge by asset
+func newGraph(flow models.FlowGraph) graph {
+	g := graph{}
+
+	for _, n := range flow.Nodes {
+		g.nodes = append(g.nodes, node{
+			id:     n.Address,
+			values: []any{n.Address, n.Depth, n.Expanded, n.Address == flow.Root},
+		})
+	}
+
+	for _, e := range flow.Edges {
+		keys := make([]string, 0, len(e.Assets))
+		for key := range e.Assets {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			a := e.Assets[key]
+			weight, _ := a.Amount.Rat().Float64()
+			g.edges = append(g.edges, edge{
+				id:     fmt.Sprintf("e%d", len(g.edges)),
+				source: e.From,
+				target: e.To,
+				label:  strings.TrimSpace(a.Amount.String() + " " + a.Symbol),
+				weight: weight,
+				values: []any{
+					a.Key, a.Symbol, a.Amount.String(), a.Amount.Raw().String(), int(a.Decimals), weight,
+					a.TxCount, utils.FormatTimestamp(a.FirstTimestamp), utils.FormatTimestamp(a.LastTimestamp), e.Created,
+				},
+			})
+		}
+	}
+
+	return g
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// graphMLDocument is a GraphML document (http://graphml.graphdrawing.org)
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLTypes maps attribute kinds to GraphML attribute types
+var graphMLTypes = map[string]string{
+	"string":  "string",
+	"int":     "int",
+	"double":  "double",
+	"boolean": "boolean",
+}
+
+// writeGraphML renders the graph as GraphML
+func writeGraphML(w io.Writer, g graph) error {
+	doc := graphMLDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Graph: graphMLGraph{ID: "flow", EdgeDefault: "directed"},
+	}
+
+	for _, a := range nodeAttributes {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "n_" + a.name, For: "node", AttrName: a.name, AttrType: graphMLTypes[a.kind]})
+	}
+	for _, a := range edgeAttributes {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "e_" + a.name, For: "edge", AttrName: a.name, AttrType: graphMLTypes[a.kind]})
+	}
+
+	for _, n := range g.nodes {
+		gn := graphMLNode{ID: n.id}
+		for i, a := range nodeAttributes {
+			gn.Data = append(gn.Data, graphMLData{Key: "n_" + a.name, Value: fmt.Sprint(n.values[i])})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gn)
+	}
+
+	for _, e := range g.edges {
+		ge := graphMLEdge{ID: e.id, Source: e.source, Target: e.target}
+		for i, a := range edgeAttributes {
+			ge.Data = append(ge.Data, graphMLData{Key: "e_" + a.name, Value: fmt.Sprint(e.values[i])})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, ge)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("error encoding GraphML: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,266 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"Ethereum-fund-flow-analysis/internal/client"
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// Job states
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Errors reported by the Manager, matched with errors.Is
+var (
+	ErrQueueFull = errors.New("job queue is full")
+	ErrNotFound  = errors.New("job not found")
+)
+
+// Func performs the work of a job and returns its result
+type Func func(ctx context.Context) (any, error)
+
+// Info is a snapshot of a job
+type Info struct {
+	ID         string
+	Analysis   string
+	Status     string
+	CreatedAt  time.Time
+	StartedAt  time.Time // Zero while queued
+	FinishedAt time.Time // Zero until the job succeeded, failed or was cancelled
+	Progress   map[string]models.ListProgress
+	Result     any   // Result of a succeeded job
+	Err        error // Error of a failed job
+}
+
+// Finished reports whether the job succeeded, failed or was cancelled
+func (i Info) Finished() bool {
+	return !i.FinishedAt.IsZero()
+}
+
+// job is a submitted job together with the state needed to run and cancel it
+type job struct {
+	mu        sync.Mutex
+	info      Info
+	progress  *client.Progress
+	run       Func
+	timeout   time.Duration
+	cancel    context.CancelFunc // Cancels the running job, nil otherwise
+	cancelled bool               // Whether the job was cancelled through the Manager
+}
+
+// snapshot returns the current state of the job
+func (j *job) snapshot() Info {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	info := j.info
+	info.Progress = j.progress.Snapshot()
+	return info
+}
+
+// Manager runs submitted jobs on a bounded pool of workers and keeps
+// finished jobs around for polling until their retention has passed
+type Manager struct {
+	queueSize int
+	retention time.Duration
+
+	mu    sync.Mutex
+	ready *sync.Cond // Signalled when a job is queued
+	queue []*job     // Queued jobs in submission order; cancelled jobs leave it at once
+	jobs  map[string]*job
+}
+
+// NewManager starts a manager with the given number of workers, queueing up to queueSize
+// jobs while they are busy. Finished jobs are dropped once retention has passed.
+func NewManager(workers, queueSize int, retention time.Duration) *Manager {
+	m := &Manager{
+		queueSize: queueSize,
+		retention: retention,
+		jobs:      map[string]*job{},
+	}
+	m.ready = sync.NewCond(&m.mu)
+	for range max(workers, 1) {
+		go m.work()
+	}
+	return m
+}
+
+// Submit queues a job running the given analysis, bounded by timeout if it is positive
+func (m *Manager) Submit(analysis string, timeout time.Duration, run Func) (Info, error) {
+	id, err := newID()
+	if err != nil {
+		return Info{}, err
+	}
+
+	j := &job{
+		info: Info{
+			ID:        id,
+			Analysis:  analysis,
+			Status:    StatusQueued,
+			CreatedAt: time.Now(),
+		},
+		progress: &client.Progress{},
+		run:      run,
+		timeout:  timeout,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.purge()
+
+	if len(m.queue) >= m.queueSize {
+		return Info{}, ErrQueueFull
+	}
+	m.queue = append(m.queue, j)
+	m.jobs[id] = j
+	m.ready.Signal()
+
+	return j.snapshot(), nil
+}
+
+// Get returns the state of a job
+func (m *Manager) Get(id string) (Info, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.purge()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return Info{}, ErrNotFound
+	}
+	return j.snapshot(), nil
+}
+
+// Cancel stops a queued or running job. A finished job is removed instead.
+func (m *Manager) Cancel(id string) (Info, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return Info{}, ErrNotFound
+	}
+
+	j.mu.Lock()
+	switch j.info.Status {
+	case StatusQueued:
+		// Free its place in the queue for other jobs
+		m.queue = slices.DeleteFunc(m.queue, func(queued *job) bool { return queued == j })
+		j.info.Status = StatusCancelled
+		j.info.FinishedAt = time.Now()
+		j.cancelled = true
+	case StatusRunning:
+		// The worker marks it cancelled once the analysis returns
+		j.cancelled = true
+		j.cancel()
+	default:
+		delete(m.jobs, id)
+	}
+	j.mu.Unlock()
+
+	return j.snapshot(), nil
+}
+
+// purge drops the finished jobs whose retention has passed. m.mu must be held.
+func (m *Manager) purge() {
+	for id, j := range m.jobs {
+		j.mu.Lock()
+		expired := !j.info.FinishedAt.IsZero() && time.Since(j.info.FinishedAt) > m.retention
+		j.mu.Unlock()
+
+		if expired {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// work runs queued jobs in submission order, waiting for one while the queue is empty
+func (m *Manager) work() {
+	for {
+		m.mu.Lock()
+		for len(m.queue) == 0 {
+			m.ready.Wait()
+		}
+		j := m.queue[0]
+		m.queue = m.queue[1:]
+		m.mu.Unlock()
+
+		m.execute(j)
+	}
+}
+
+// execute runs a single job, recording its outcome
+func (m *Manager) execute(j *job) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if j.timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), j.timeout)
+	}
+	defer cancel()
+
+	j.mu.Lock()
+	if j.info.Status != StatusQueued {
+		// Cancelled while queued
+		j.mu.Unlock()
+		return
+	}
+	j.info.Status = StatusRunning
+	j.info.StartedAt = time.Now()
+	j.cancel = cancel
+	j.mu.Unlock()
+
+	result, err := run(client.WithProgress(ctx, j.progress), j.run)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.cancel = nil
+	j.info.FinishedAt = time.Now()
+	switch {
+	case j.cancelled:
+		j.info.Status = StatusCancelled
+	case err != nil:
+		j.info.Status = StatusFailed
+		j.info.Err = err
+	default:
+		j.info.Status = StatusSucceeded
+		j.info.Result = result
+	}
+}
+
+// run calls f, reporting a panic as its error so that a failing analysis fails its job
+// instead of the server
+func run(ctx context.Context, f Func) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job panicked: %v\n%s", r, debug.Stack())
+			result, err = nil, fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return f(ctx)
+}
+
+// newID generates a random job ID
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
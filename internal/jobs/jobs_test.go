@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitFinished polls the job until it finished, failing the test after a second
+func waitFinished(t *testing.T, m *Manager, id string) Info {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		info, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", id, err)
+		}
+		if info.Finished() {
+			return info
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Info{}
+}
+
+func TestPanicFailsJob(t *testing.T) {
+	m := NewManager(1, 1, time.Minute)
+
+	info, err := m.Submit("test", 0, func(ctx context.Context) (any, error) {
+		panic("boom")
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	info = waitFinished(t, m, info.ID)
+	if info.Status != StatusFailed || info.Err == nil || !strings.Contains(info.Err.Error(), "boom") {
+		t.Errorf("job %s with error %v, want %s with the panic", info.Status, info.Err, StatusFailed)
+	}
+
+	// The worker survives to run the next job
+	info, err = m.Submit("test", 0, func(ctx context.Context) (any, error) { return 1, nil })
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if info = waitFinished(t, m, info.ID); info.Status != StatusSucceeded {
+		t.Errorf("job %s after a panic, want %s", info.Status, StatusSucceeded)
+	}
+}
+
+func TestCancelFreesQueue(t *testing.T) {
+	m := NewManager(1, 1, time.Minute)
+
+	// Keep the only worker busy
+	release := make(chan struct{})
+	busy, err := m.Submit("test", 0, func(ctx context.Context) (any, error) {
+		<-release
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	for info, _ := m.Get(busy.ID); info.Status != StatusRunning; info, _ = m.Get(busy.ID) {
+		time.Sleep(time.Millisecond)
+	}
+
+	noop := func(ctx context.Context) (any, error) { return nil, nil }
+	queued, err := m.Submit("test", 0, noop)
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if _, err := m.Submit("test", 0, noop); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Submit() to a full queue error = %v, want %v", err, ErrQueueFull)
+	}
+
+	if _, err := m.Cancel(queued.ID); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	next, err := m.Submit("test", 0, noop)
+	if err != nil {
+		t.Fatalf("Submit() after cancelling the queued job error = %v", err)
+	}
+
+	close(release)
+	if info := waitFinished(t, m, next.ID); info.Status != StatusSucceeded {
+		t.Errorf("job %s, want %s", info.Status, StatusSucceeded)
+	}
+	if info, _ := m.Get(queued.ID); info.Status != StatusCancelled || !info.StartedAt.IsZero() {
+		t.Errorf("cancelled job %s started at %v, want %s and never started", info.Status, info.StartedAt, StatusCancelled)
+	}
+}
@@ -0,0 +1,132 @@
+package labels
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// csvColumns are the columns of a label CSV file, of which address, name and category are required
+var csvColumns = []string{"address", "name", "category", "source", "confidence"}
+
+// ReadFile reads the labels of a CSV or JSON file, chosen by its extension.
+// Labels without a source get defaultSource.
+func ReadFile(path, defaultSource string) ([]models.Label, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read labels: %w", err)
+	}
+	defer file.Close()
+
+	var labels []models.Label
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		labels, err = ReadCSV(file, defaultSource)
+	case ".json":
+		labels, err = ReadJSON(file, defaultSource)
+	default:
+		return nil, fmt.Errorf("failed to read labels: %s is neither a .csv nor a .json file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read labels from %s: %w", path, err)
+	}
+	return labels, nil
+}
+
+// ReadCSV reads labels from CSV with a header row naming its columns, see csvColumns.
+// Labels without a source get defaultSource.
+func ReadCSV(reader io.Reader, defaultSource string) ([]models.Label, error) {
+	records := csv.NewReader(reader)
+	records.FieldsPerRecord = -1
+	records.TrimLeadingSpace = true
+
+	header, err := records.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Map columns to their position
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvColumns[:3] {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %s column", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var labels []models.Label
+	for {
+		record, err := records.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		label := models.Label{
+			Address:  field(record, "address"),
+			Name:     field(record, "name"),
+			Category: field(record, "category"),
+			Source:   field(record, "source"),
+		}
+		if confidence := field(record, "confidence"); confidence != "" {
+			if label.Confidence, err = strconv.ParseFloat(confidence, 64); err != nil {
+				line, _ := records.FieldPos(0)
+				return nil, fmt.Errorf("line %d: invalid confidence %q", line, confidence)
+			}
+		}
+		if label.Source == "" {
+			label.Source = defaultSource
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+// ReadJSON reads labels from a JSON array of labels, or a single label.
+// Labels without a source get defaultSource.
+func ReadJSON(reader io.Reader, defaultSource string) ([]models.Label, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var labels []models.Label
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		var label models.Label
+		if err := json.Unmarshal(data, &label); err != nil {
+			return nil, err
+		}
+		labels = []models.Label{label}
+	} else if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, err
+	}
+
+	for i := range labels {
+		if labels[i].Source == "" {
+			labels[i].Source = defaultSource
+		}
+	}
+	return labels, nil
+}
@@ -0,0 +1,280 @@
+package labels
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// Categories are the categories a label can have
+var Categories = []string{"exchange", "bridge", "mixer", "dex", "scam", "sanctioned"}
+
+// ErrInvalidLabel is returned for labels that cannot be stored, matched with errors.Is
+var ErrInvalidLabel = errors.New("invalid label")
+
+// addressPattern matches an Ethereum address
+var addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// Registry holds the labels of addresses, keyed by lowercase address and then by source.
+// Labels changed through the registry are saved to its file.
+type Registry struct {
+	path string // JSON file the labels are saved to, empty to keep them in memory
+
+	mu     sync.RWMutex
+	labels map[string]map[string]models.Label
+}
+
+// Load creates a registry from the JSON file at path if it exists, then imports the label
+// files in imports, which replace stored labels of the same address and source. An import
+// that is a directory stands for the .csv and .json files in it, in name order.
+func Load(path string, imports []string) (*Registry, error) {
+	r := &Registry{path: path, labels: map[string]map[string]models.Label{}}
+
+	if path != "" {
+		labels, err := ReadFile(path, "")
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if _, err := r.add(labels); err != nil {
+			return nil, fmt.Errorf("label registry %s: %w", path, err)
+		}
+	}
+
+	files, err := expand(imports)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		labels, err := ReadFile(file, strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.add(labels); err != nil {
+			return nil, fmt.Errorf("label file %s: %w", file, err)
+		}
+	}
+
+	return r, nil
+}
+
+// expand replaces the directories in paths by the label files they contain
+func expand(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read labels: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read labels: %w", err)
+		}
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if !entry.IsDir() && (ext == ".csv" || ext == ".json") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+// Normalize validates a label and brings it into its stored form: a lowercase address and
+// category, and a confidence of 1 if none is given
+func Normalize(label models.Label) (models.Label, error) {
+	label.Address = strings.ToLower(strings.TrimSpace(label.Address))
+	label.Name = strings.TrimSpace(label.Name)
+	label.Category = strings.ToLower(strings.TrimSpace(label.Category))
+	label.Source = strings.TrimSpace(label.Source)
+
+	if !addressPattern.MatchString(label.Address) {
+		return label, fmt.Errorf("%w: invalid address %q", ErrInvalidLabel, label.Address)
+	}
+	if label.Name == "" {
+		return label, fmt.Errorf("%w: %s needs a name", ErrInvalidLabel, label.Address)
+	}
+	if !slices.Contains(Categories, label.Category) {
+		return label, fmt.Errorf("%w: invalid category %q for %s, expected one of %s",
+			ErrInvalidLabel, label.Category, label.Address, strings.Join(Categories, ", "))
+	}
+	if label.Source == "" {
+		return label, fmt.Errorf("%w: %s needs a source", ErrInvalidLabel, label.Address)
+	}
+	if label.Confidence == 0 {
+		label.Confidence = 1
+	}
+	if label.Confidence < 0 || label.Confidence > 1 {
+		return label, fmt.Errorf("%w: confidence of %s must be between 0 and 1", ErrInvalidLabel, label.Address)
+	}
+
+	return label, nil
+}
+
+// add normalizes and stores labels, replacing those of the same address and source,
+// and returns them as stored. Nothing is stored if any label is invalid.
+func (r *Registry) add(labels []models.Label) ([]models.Label, error) {
+	normalized := make([]models.Label, 0, len(labels))
+	for _, label := range labels {
+		label, err := Normalize(label)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, label)
+	}
+
+	for _, label := range normalized {
+		sources, ok := r.labels[label.Address]
+		if !ok {
+			sources = map[string]models.Label{}
+			r.labels[label.Address] = sources
+		}
+		sources[label.Source] = label
+	}
+	return normalized, nil
+}
+
+// Lookup returns the labels of an address, most confident first
+func (r *Registry) Lookup(address string) []models.Label {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return sorted(r.labels[strings.ToLower(address)])
+}
+
+// List returns every label, optionally only those of the given category and source,
+// ordered by address and then by confidence
+func (r *Registry) List(category, source string) []models.Label {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	addresses := make([]string, 0, len(r.labels))
+	for address := range r.labels {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	list := []models.Label{}
+	for _, address := range addresses {
+		for _, label := range sorted(r.labels[address]) {
+			if category != "" && label.Category != strings.ToLower(category) {
+				continue
+			}
+			if source != "" && label.Source != source {
+				continue
+			}
+			list = append(list, label)
+		}
+	}
+	return list
+}
+
+// Put stores labels, replacing those of the same address and source, saves the registry
+// and returns the labels as stored
+func (r *Registry) Put(labels ...models.Label) ([]models.Label, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.add(labels)
+	if err != nil {
+		return nil, err
+	}
+	return stored, r.save()
+}
+
+// Delete removes the label of an address from source, or all of its labels if source is
+// empty, saves the registry and returns the labels removed
+func (r *Registry) Delete(address, source string) ([]models.Label, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	address = strings.ToLower(address)
+	sources := r.labels[address]
+
+	var removed []models.Label
+	if source == "" {
+		removed = sorted(sources)
+		delete(r.labels, address)
+	} else if label, ok := sources[source]; ok {
+		removed = []models.Label{label}
+		delete(sources, source)
+		if len(sources) == 0 {
+			delete(r.labels, address)
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return removed, r.save()
+}
+
+// save writes every label to the registry file, replacing it atomically. r.mu must be held.
+func (r *Registry) save() error {
+	if r.path == "" {
+		return nil
+	}
+
+	all := []models.Label{}
+	for _, sources := range r.labels {
+		all = append(all, sorted(sources)...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Address < all[j].Address
+	})
+
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling labels: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("error creating label directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error writing labels: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), r.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error writing labels: %w", err)
+	}
+
+	return nil
+}
+
+// sorted returns the labels of one address, most confident first and then by source
+func sorted(sources map[string]models.Label) []models.Label {
+	list := make([]models.Label, 0, len(sources))
+	for _, label := range sources {
+		list = append(list, label)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Confidence != list[j].Confidence {
+			return list[i].Confidence > list[j].Confidence
+		}
+		return list[i].Source < list[j].Source
+	})
+	return list
+}
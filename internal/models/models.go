@@ -1,47 +1,376 @@
 package models
 
 import (
+	"encoding/json"
+	"time"
+
 	"Ethereum-fund-flow-analysis/internal/utils"
 )
 
+// NativeAsset is the asset key of the chain's native coin
+const NativeAsset = "native"
+
+// NFTAssetKey returns the asset key of the NFTs of a contract that is also an ERC-20 token,
+// such as an ERC-404 token, keeping them apart from the token as they do not share decimals
+func NFTAssetKey(contract, standard string) string {
+	return contract + ":" + standard
+}
+
+// Transfer types, one per Etherscan transaction list
+const (
+	TransferNormal   = "normal"
+	TransferInternal = "internal"
+	TransferERC20    = "erc20"
+	TransferERC721   = "erc721"
+	TransferERC1155  = "erc1155"
+)
+
+// Flow kinds, distinguishing value sent to a contract as it is created
+const (
+	FlowTransfer = "transfer" // Value moved to an existing account
+	FlowCreate   = "create"   // Value sent to a contract by the transaction or call creating it
+)
+
+// Account types of counterparties
+const (
+	AccountEOA             = "eoa"                        // Externally owned account
+	AccountContract        = "contract"                   // Contract created by someone else
+	AccountCreatedByTarget = "contract_created_by_target" // Contract created by the analyzed address
+	AccountPrecompile      = "precompile"                 // Precompiled contract
+)
+
+// AccountTypes are the account types in order of precedence, e.g. an address that is
+// a contract on one chain of a cross-chain analysis and an EOA on another is a contract
+var AccountTypes = []string{AccountPrecompile, AccountCreatedByTarget, AccountContract, AccountEOA}
+
+// Asset identifies the native coin or a token contract
+type Asset struct {
+	Key      string `json:"asset"` // NativeAsset or the lowercase token contract address
+	Standard string `json:"standard"`
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name,omitempty"`
+	Decimals uint8  `json:"decimals"`
+	ChainId  int    `json:"chain_id,omitempty"` // Chain of the asset in a cross-chain analysis
+}
+
+// AssetAmount is the aggregated amount moved in a single asset
+type AssetAmount struct {
+	Asset
+	Amount         utils.Amount `json:"amount"`
+	TxCount        int          `json:"tx_count"`
+	FirstTimestamp int64        `json:"first_timestamp"` // Unix time of the earliest transfer
+	LastTimestamp  int64        `json:"last_timestamp"`  // Unix time of the latest transfer
+}
+
+// AddTransfer counts a transfer of amount made at timestamp
+func (a *AssetAmount) AddTransfer(amount utils.Amount, timestamp int64) {
+	a.Amount = a.Amount.Add(amount)
+	if a.TxCount == 0 || timestamp < a.FirstTimestamp {
+		a.FirstTimestamp = timestamp
+	}
+	if a.TxCount == 0 || timestamp > a.LastTimestamp {
+		a.LastTimestamp = timestamp
+	}
+	a.TxCount++
+}
+
 // Transaction represents a single transaction in the response
 type Transaction struct {
-	TxAmount      float64 `json:"tx_amount"`
-	DateTime      string  `json:"date_time"`
-	TransactionID string  `json:"transaction_id"`
+	TxAmount      utils.Amount `json:"tx_amount"`
+	DateTime      string       `json:"date_time"`
+	Timestamp     int64        `json:"timestamp"` // Unix time of the transaction
+	TransactionID string       `json:"transaction_id"`
+	Type          string       `json:"type"`
+	Kind          string       `json:"kind"` // FlowTransfer or FlowCreate
+	Asset         string       `json:"asset"`
+	Symbol        string       `json:"symbol"`
+	TokenID       string       `json:"token_id,omitempty"`
+	ChainId       int          `json:"chain_id"`
+}
+
+// ChainSubtotal is the native amount and transaction count of a counterparty on one chain
+type ChainSubtotal struct {
+	Symbol  string       `json:"symbol"` // Symbol of the chain's native coin
+	Amount  utils.Amount `json:"amount"` // Native coin amount
+	TxCount int          `json:"tx_count"`
 }
 
 // Beneficiary represents a single beneficiary with all related transactions
 type Beneficiary struct {
-	Address      string        `json:"beneficiary_address"`
-	Amount       float64       `json:"amount"`
-	Transactions []Transaction `json:"transactions"`
+	Address      string                  `json:"beneficiary_address"`
+	Amount       utils.Amount            `json:"amount"` // Native coin amount
+	Assets       map[string]*AssetAmount `json:"assets"`
+	Chains       map[int]*ChainSubtotal  `json:"chains,omitempty"`            // Per-chain subtotals of a cross-chain analysis
+	Labels       []Label                 `json:"labels,omitempty"`            // Labels of the address in the label registry
+	AccountType  string                  `json:"account_type,omitempty"`      // Set when account types are requested
+	Created      bool                    `json:"contract_creation,omitempty"` // A transaction created the counterparty, or it created the target
+	Transactions []Transaction           `json:"transactions"`
+}
+
+// Label identifies the owner or nature of an address, according to one source
+type Label struct {
+	Address    string  `json:"address"`
+	Name       string  `json:"name"`       // e.g. "Binance 14"
+	Category   string  `json:"category"`   // "exchange", "bridge", "mixer", "dex", "scam" or "sanctioned"
+	Source     string  `json:"source"`     // Where the label comes from; an address has at most one label per source
+	Confidence float64 `json:"confidence"` // From 0 to 1
+}
+
+// ScreeningMatch is a sanctioned address reached by an analysis
+type ScreeningMatch struct {
+	Address  string   `json:"address"`
+	List     string   `json:"list"` // Sanctions list naming the address
+	Name     string   `json:"name"` // Sanctioned party
+	Programs []string `json:"programs,omitempty"`
+	Hops     int      `json:"hops"`      // 0 for the target, 1 for its counterparties, more along a trace
+	TxHashes []string `json:"tx_hashes"` // Transactions exposing the target to the address
+}
+
+// Screening is the outcome of checking the addresses of an analysis against the sanctions lists
+type Screening struct {
+	Lists   []string         `json:"lists"` // Lists checked against
+	Matched bool             `json:"matched"`
+	Matches []ScreeningMatch `json:"matches"`
+}
+
+// SanctionsList describes a loaded sanctions list
+type SanctionsList struct {
+	Name       string    `json:"name"`
+	Path       string    `json:"path"`
+	Addresses  int       `json:"addresses"`
+	ModifiedAt time.Time `json:"modified_at"` // Modification time of the file the addresses were loaded from
+	LoadedAt   time.Time `json:"loaded_at"`
+	Error      string    `json:"error,omitempty"` // Why the last reload failed; the previous addresses stay in use
+}
+
+// SanctionsListsResponse is the complete response for the /screening endpoints
+type SanctionsListsResponse struct {
+	Message string          `json:"message"`
+	Data    []SanctionsList `json:"data"`
+}
+
+// LabelsResponse is the complete response for the /labels endpoints
+type LabelsResponse struct {
+	Message string  `json:"message"`
+	Data    []Label `json:"data"`
+}
+
+// ErrorDetail describes why a request failed
+type ErrorDetail struct {
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// ErrorResponse is the response sent when a request fails
+type ErrorResponse struct {
+	Message string      `json:"message"`
+	Error   ErrorDetail `json:"error"`
+}
+
+// CacheStatus counts the transaction lists served from and missing in the response cache
+type CacheStatus struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+// NativeCurrency describes the native coin of a chain
+type NativeCurrency struct {
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// Chain holds the metadata of a supported chain
+type Chain struct {
+	ID          int            `json:"chain_id"`
+	Name        string         `json:"name"`
+	Native      NativeCurrency `json:"native_currency"`
+	ExplorerURL string         `json:"explorer_url,omitempty"`
+	APIURL      string         `json:"api_url,omitempty"`    // Etherscan-compatible API of the chain, empty for the configured base URL
+	BlockTime   float64        `json:"block_time,omitempty"` // Average block time in seconds, 0 if unknown
+	Testnet     bool           `json:"testnet,omitempty"`    // Test network, left out of chainid=all
+}
+
+// ChainsResponse is the complete response for the /chains endpoint
+type ChainsResponse struct {
+	Message string  `json:"message"`
+	Data    []Chain `json:"data"`
+}
+
+// FetchStatus reports how completely the transaction lists behind a result were retrieved
+type FetchStatus struct {
+	Complete  bool          `json:"complete"`
+	Truncated []string      `json:"truncated,omitempty"` // Transaction lists that may be missing records
+	Cache     *CacheStatus  `json:"cache,omitempty"`
+	Chains    []ChainStatus `json:"chains,omitempty"`   // Outcome per chain of a cross-chain analysis
+	Warnings  []Warning     `json:"warnings,omitempty"` // Transaction lists left out of a partial result, or account types not looked up
+}
+
+// Warning names a transaction list that could not be retrieved and was left out of a partial result,
+// or account types that were not looked up
+type Warning struct {
+	TransactionType string `json:"transaction_type"`
+	Address         string `json:"address,omitempty"`  // Address whose list failed, for analyses covering several
+	ChainId         int    `json:"chain_id,omitempty"` // Chain whose list failed, for cross-chain analyses
+	Error           string `json:"error"`
+}
+
+// ChainStatus reports how completely the transactions of one chain of a cross-chain analysis were retrieved
+type ChainStatus struct {
+	ChainId   int      `json:"chain_id"`
+	Name      string   `json:"name"`
+	Complete  bool     `json:"complete"`
+	Truncated []string `json:"truncated,omitempty"`
+	Error     string   `json:"error,omitempty"` // Why the chain is missing from the result
 }
 
 // BeneficiaryResponse is the complete response for the /beneficiary endpoint
 type BeneficiaryResponse struct {
-	Message string        `json:"message"`
-	Data    []Beneficiary `json:"data"`
+	Message string `json:"message"`
+	FetchStatus
+	Data      []Beneficiary `json:"data"`
+	Screening *Screening    `json:"screening,omitempty"` // Sanctions screening, when lists are configured
 }
 
 // Payer represents a single payer with all related transactions
 type Payer struct {
-	Address      string        `json:"payer_address"`
-	Amount       float64       `json:"amount"`
-	Transactions []Transaction `json:"transactions"`
+	Address      string                  `json:"payer_address"`
+	Amount       utils.Amount            `json:"amount"` // Native coin amount
+	Assets       map[string]*AssetAmount `json:"assets"`
+	Chains       map[int]*ChainSubtotal  `json:"chains,omitempty"`            // Per-chain subtotals of a cross-chain analysis
+	Labels       []Label                 `json:"labels,omitempty"`            // Labels of the address in the label registry
+	AccountType  string                  `json:"account_type,omitempty"`      // Set when account types are requested
+	Created      bool                    `json:"contract_creation,omitempty"` // A transaction created the counterparty, or it created the target
+	Transactions []Transaction           `json:"transactions"`
 }
 
 // EntityWithTransactions is a common interface for both Beneficiary and Payer
 type EntityWithTransactions struct {
 	Address      string
-	Amount       float64
+	Amount       utils.Amount
+	Assets       map[string]*AssetAmount
+	Chains       map[int]*ChainSubtotal
+	Created      bool // A transaction created the counterparty, or it created the target
 	Transactions []Transaction
 }
 
+// AmountOf returns the amount moved in the given asset,
+// or the native amount if asset is empty
+func (e *EntityWithTransactions) AmountOf(asset string) utils.Amount {
+	return AssetAmountOf(e.Amount, e.Assets, asset)
+}
+
+// AssetAmountOf returns the amount of asset in assets, or nativeAmount if asset is empty
+func AssetAmountOf(nativeAmount utils.Amount, assets map[string]*AssetAmount, asset string) utils.Amount {
+	if asset == "" {
+		return nativeAmount
+	}
+	if a, ok := assets[asset]; ok {
+		return a.Amount
+	}
+	return utils.Amount{}
+}
+
 // PayerResponse is the complete response for the /payer endpoint
 type PayerResponse struct {
-	Message string  `json:"message"`
-	Data    []Payer `json:"data"`
+	Message string `json:"message"`
+	FetchStatus
+	Data      []Payer    `json:"data"`
+	Screening *Screening `json:"screening,omitempty"` // Sanctions screening, when lists are configured
+}
+
+// AssetFlow is the movement of a single asset between an address and one counterparty
+type AssetFlow struct {
+	Asset
+	Inflow         utils.Amount `json:"inflow"`  // Received from the counterparty
+	Outflow        utils.Amount `json:"outflow"` // Sent to the counterparty
+	Net            utils.Amount `json:"net"`     // Inflow minus outflow
+	InCount        int          `json:"in_count"`
+	OutCount       int          `json:"out_count"`
+	FirstTimestamp int64        `json:"first_timestamp"` // Unix time of the first interaction
+	LastTimestamp  int64        `json:"last_timestamp"`  // Unix time of the latest interaction
+}
+
+// Counterparty is the net flow between an address and one counterparty
+type Counterparty struct {
+	Address        string                `json:"counterparty_address"`
+	Inflow         utils.Amount          `json:"inflow"`  // Native coin received from the counterparty
+	Outflow        utils.Amount          `json:"outflow"` // Native coin sent to the counterparty
+	Net            utils.Amount          `json:"net"`     // Native inflow minus outflow
+	InCount        int                   `json:"in_count"`
+	OutCount       int                   `json:"out_count"`
+	FirstTimestamp int64                 `json:"first_timestamp"`
+	LastTimestamp  int64                 `json:"last_timestamp"`
+	Assets         map[string]*AssetFlow `json:"assets"`
+	Labels         []Label               `json:"labels,omitempty"`       // Labels of the address in the label registry
+	AccountType    string                `json:"account_type,omitempty"` // Set when account types are requested
+	TxHashes       []string              `json:"-"`                      // Transactions with the counterparty, for screening
+}
+
+// CounterpartiesResponse is the complete response for the /counterparties endpoint
+type CounterpartiesResponse struct {
+	Message string `json:"message"`
+	FetchStatus
+	Data      []Counterparty `json:"data"`
+	Screening *Screening     `json:"screening,omitempty"` // Sanctions screening, when lists are configured
+}
+
+// BucketFlow is the movement of a single asset during one time bucket
+type BucketFlow struct {
+	Asset
+	Inflow            utils.Amount `json:"inflow"`
+	Outflow           utils.Amount `json:"outflow"`
+	InCount           int          `json:"in_count"`
+	OutCount          int          `json:"out_count"`
+	InCounterparties  int          `json:"in_counterparties"`  // Distinct addresses funds were received from
+	OutCounterparties int          `json:"out_counterparties"` // Distinct addresses funds were sent to
+	Counterparties    int          `json:"counterparties"`     // Distinct addresses in either direction
+}
+
+// TimeBucket holds the flows of every asset moved during one interval
+type TimeBucket struct {
+	Start    int64                  `json:"start"`     // Unix time the bucket starts at
+	DateTime string                 `json:"date_time"` // Start of the bucket in RFC 3339, UTC
+	Assets   map[string]*BucketFlow `json:"assets"`
+}
+
+// TimeSeries is the activity of an address binned by time
+type TimeSeries struct {
+	Address  string       `json:"address"`
+	Interval string       `json:"interval"` // "hour", "day", "week" or "month"
+	Buckets  []TimeBucket `json:"buckets"`  // Buckets with activity, in chronological order
+
+	Counterparties map[string][]string `json:"-"` // Transactions binned, by lowercase counterparty address, for screening
+}
+
+// TimeSeriesResponse is the complete response for the /timeseries endpoint
+type TimeSeriesResponse struct {
+	Message string `json:"message"`
+	FetchStatus
+	Data      TimeSeries `json:"data"`
+	Screening *Screening `json:"screening,omitempty"` // Sanctions screening, when lists are configured
+}
+
+// LedgerEntry is a single movement of value touching an address, as streamed by /ledger
+type LedgerEntry struct {
+	Type         string `json:"type"`
+	Kind         string `json:"kind"` // FlowTransfer or FlowCreate
+	Hash         string `json:"hash"`
+	Block        int64  `json:"block"`
+	Timestamp    int64  `json:"timestamp"`
+	DateTime     string `json:"date_time"`
+	Direction    string `json:"direction"` // "in", "out" or "self"
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Counterparty string `json:"counterparty"`
+	Asset        string `json:"asset"`
+	Symbol       string `json:"symbol"`
+	TokenID      string `json:"token_id"`
+	Value        string `json:"value"` // Raw value in the asset's base units
+	Decimals     uint8  `json:"decimals"`
+	GasUsed      int    `json:"gas_used"`
+	GasPrice     string `json:"gas_price"` // Empty for internal transactions
+	Status       string `json:"status"`    // "success" or "failed"
 }
 
 // NormalTx holds info from normal tx query
@@ -156,9 +485,156 @@ type ERC1155Transfer struct {
 	Confirmations     int           `json:"confirmations,string"`
 }
 
+// Block returns the block the transaction was included in
+func (tx NormalTx) Block() int { return tx.BlockNumber }
+
+// Block returns the block the transaction was included in
+func (tx InternalTx) Block() int { return tx.BlockNumber }
+
+// Block returns the block the transfer was included in
+func (tx ERC20Transfer) Block() int { return tx.BlockNumber }
+
+// Block returns the block the transfer was included in
+func (tx ERC721Transfer) Block() int { return tx.BlockNumber }
+
+// Block returns the block the transfer was included in
+func (tx ERC1155Transfer) Block() int { return tx.BlockNumber }
+
+// ContractCreation describes how a contract was deployed
+type ContractCreation struct {
+	ContractAddress string     `json:"contractAddress"`
+	ContractCreator string     `json:"contractCreator"`
+	TxHash          string     `json:"txHash"`
+	BlockNumber     int64      `json:"blockNumber,string"`
+	TimeStamp       utils.Time `json:"timestamp"`
+}
+
 // EtherscanResponse is the generic response structure from Etherscan API
 type EtherscanResponse struct {
-	Status  string      `json:"status"`
-	Message string      `json:"message"`
-	Result  interface{} `json:"result"`
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// FlowNode is an address reached while tracing funds
+type FlowNode struct {
+	Address  string  `json:"address"`
+	Depth    int     `json:"depth"`
+	Expanded bool    `json:"expanded"`
+	Labels   []Label `json:"labels,omitempty"` // Labels of the address in the label registry
+}
+
+// FlowEdge is the aggregated movement of funds between two addresses
+type FlowEdge struct {
+	From     string                  `json:"from"`
+	To       string                  `json:"to"`
+	Amount   utils.Amount            `json:"amount"` // Native coin amount
+	Assets   map[string]*AssetAmount `json:"assets"`
+	TxHashes []string                `json:"tx_hashes"`
+	Created  bool                    `json:"contract_creation,omitempty"` // From created To in one of the transactions
+}
+
+// FlowGraph is the result of a multi-hop trace starting at Root
+type FlowGraph struct {
+	Root      string     `json:"root"`
+	Direction string     `json:"direction"`
+	Nodes     []FlowNode `json:"nodes"`
+	Edges     []FlowEdge `json:"edges"`
+}
+
+// TraceResponse is the complete response for the /trace endpoint
+type TraceResponse struct {
+	Message string `json:"message"`
+	FetchStatus
+	Data      FlowGraph  `json:"data"`
+	Screening *Screening `json:"screening,omitempty"` // Sanctions screening, when lists are configured
+}
+
+// RiskEvidence is a transfer supporting a risk factor
+type RiskEvidence struct {
+	TxHashes  []string `json:"tx_hashes"`
+	Timestamp int64    `json:"timestamp"`
+	Detail    string   `json:"detail"`
+}
+
+// RiskFactor is one explained contribution to a risk score
+type RiskFactor struct {
+	Name          string         `json:"name"`
+	Description   string         `json:"description"` // What was measured
+	Weight        float64        `json:"weight"`      // Share of the score the factor can contribute
+	Signal        float64        `json:"signal"`      // Strength of the factor from 0 to 1
+	Score         float64        `json:"score"`       // Points contributed: weight × signal × 100
+	Evidence      []RiskEvidence `json:"evidence"`
+	EvidenceCount int            `json:"evidence_count"`        // Evidence found, of which the first are listed
+	Unavailable   string         `json:"unavailable,omitempty"` // Why the factor could not be evaluated
+}
+
+// RiskReport is the explainable risk score of an address
+type RiskReport struct {
+	Address string       `json:"address"`
+	ChainId int          `json:"chain_id"`
+	Score   float64      `json:"score"` // From 0 to 100, the sum of the factor scores
+	Level   string       `json:"level"` // "low", "medium", "high" or "critical"
+	Factors []RiskFactor `json:"factors"`
+}
+
+// RiskResponse is the complete response for the /risk endpoint
+type RiskResponse struct {
+	Message string `json:"message"`
+	FetchStatus
+	Data RiskReport `json:"data"`
+}
+
+// ListProgress counts the pages and records fetched for one transfer type
+type ListProgress struct {
+	Pages   int `json:"pages"`
+	Records int `json:"records"`
+}
+
+// Job describes an analysis running in the background
+type Job struct {
+	ID         string                  `json:"id"`
+	Analysis   string                  `json:"analysis"`
+	Status     string                  `json:"status"` // "queued", "running", "succeeded", "failed" or "cancelled"
+	CreatedAt  time.Time               `json:"created_at"`
+	StartedAt  *time.Time              `json:"started_at,omitempty"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+	Progress   map[string]ListProgress `json:"progress"`         // Pages and records fetched per transfer type
+	Result     any                     `json:"result,omitempty"` // Response of the analysis' endpoint once succeeded
+	Error      *ErrorDetail            `json:"error,omitempty"`
+}
+
+// JobResponse is the complete response for the /jobs endpoints
+type JobResponse struct {
+	Message string `json:"message"`
+	Data    Job    `json:"data"`
+}
+
+// BatchResult is the outcome of the analysis of one address of a batch
+type BatchResult struct {
+	Address string `json:"address"`
+	FetchStatus
+	Data      any          `json:"data,omitempty"` // Beneficiaries or payers of the address, as returned by their endpoint
+	Screening *Screening   `json:"screening,omitempty"`
+	Error     *ErrorDetail `json:"error,omitempty"`
+}
+
+// SharedCounterparty is a counterparty that several addresses of a batch transacted with
+type SharedCounterparty struct {
+	Address     string                  `json:"address"`
+	Addresses   []string                `json:"addresses"` // Addresses of the batch it transacted with
+	Amount      utils.Amount            `json:"amount"`    // Native coin amount across those addresses
+	Assets      map[string]*AssetAmount `json:"assets"`
+	Labels      []Label                 `json:"labels,omitempty"`       // Labels of the counterparty in the label registry
+	AccountType string                  `json:"account_type,omitempty"` // Set when account types are requested
+	TxCount     int                     `json:"tx_count"`
+}
+
+// BatchResponse is the complete response for the /batch endpoint
+type BatchResponse struct {
+	Message  string               `json:"message"`
+	Analysis string               `json:"analysis"`
+	Complete bool                 `json:"complete"` // Whether every address was analyzed completely
+	Results  []BatchResult        `json:"results"`  // One per address, in the requested order
+	Shared   []SharedCounterparty `json:"shared"`   // Counterparties shared by at least min_shared addresses
 }
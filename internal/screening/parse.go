@@ -0,0 +1,179 @@
+package screening
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// addressPattern matches an Ethereum address
+var addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// digitalCurrencyID is the prefix of the SDN ID types holding digital currency addresses,
+// e.g. "Digital Currency Address - ETH"
+const digitalCurrencyID = "Digital Currency Address"
+
+// sdnEntry is an entry of an OFAC SDN XML list
+type sdnEntry struct {
+	FirstName string   `xml:"firstName"`
+	LastName  string   `xml:"lastName"`
+	Programs  []string `xml:"programList>program"`
+	IDs       []struct {
+		Type   string `xml:"idType"`
+		Number string `xml:"idNumber"`
+	} `xml:"idList>id"`
+}
+
+// ReadFile reads the Ethereum addresses of a sanctions list, chosen by its extension:
+// OFAC SDN XML (.xml), CSV with a header row (.csv), or one address per line (.txt)
+func ReadFile(path string) (map[string]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sanctions list: %w", err)
+	}
+	defer file.Close()
+
+	var entries map[string]Entry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		entries, err = ReadSDN(file)
+	case ".csv":
+		entries, err = ReadCSV(file)
+	case ".txt":
+		entries, err = ReadText(file)
+	default:
+		return nil, fmt.Errorf("%s is neither a .xml, .csv nor .txt file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// ReadSDN reads the Ethereum addresses listed as digital currency addresses
+// of the entries of an OFAC SDN XML list
+func ReadSDN(reader io.Reader) (map[string]Entry, error) {
+	entries := map[string]Entry{}
+	decoder := xml.NewDecoder(reader)
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "sdnEntry" {
+			continue
+		}
+
+		var sdn sdnEntry
+		if err := decoder.DecodeElement(&sdn, &start); err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimSpace(sdn.FirstName + " " + sdn.LastName)
+		for _, id := range sdn.IDs {
+			address := strings.TrimSpace(id.Number)
+			if !strings.HasPrefix(id.Type, digitalCurrencyID) || !addressPattern.MatchString(address) {
+				continue
+			}
+			add(entries, Entry{Address: address, Name: name, Programs: sdn.Programs})
+		}
+	}
+
+	return entries, nil
+}
+
+// ReadCSV reads addresses from CSV with a header row naming an address column and
+// optionally name and programs columns, the programs separated by semicolons
+func ReadCSV(reader io.Reader) (map[string]Entry, error) {
+	records := csv.NewReader(reader)
+	records.FieldsPerRecord = -1
+	records.TrimLeadingSpace = true
+
+	header, err := records.Read()
+	if errors.Is(err, io.EOF) {
+		return map[string]Entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Map columns to their position
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["address"]; !ok {
+		return nil, errors.New("missing address column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	entries := map[string]Entry{}
+	for {
+		record, err := records.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		address := field(record, "address")
+		if !addressPattern.MatchString(address) {
+			line, _ := records.FieldPos(0)
+			return nil, fmt.Errorf("line %d: invalid address %q", line, address)
+		}
+
+		entry := Entry{Address: address, Name: field(record, "name")}
+		for _, program := range strings.Split(field(record, "programs"), ";") {
+			if program = strings.TrimSpace(program); program != "" {
+				entry.Programs = append(entry.Programs, program)
+			}
+		}
+		add(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// ReadText reads one address per line, skipping blank lines and lines starting with #
+func ReadText(reader io.Reader) (map[string]Entry, error) {
+	entries := map[string]Entry{}
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		address := strings.TrimSpace(scanner.Text())
+		if address == "" || strings.HasPrefix(address, "#") {
+			continue
+		}
+		if !addressPattern.MatchString(address) {
+			return nil, fmt.Errorf("line %d: invalid address %q", line, address)
+		}
+		add(entries, Entry{Address: address})
+	}
+	return entries, scanner.Err()
+}
+
+// add stores an entry under its lowercase address, keeping the first entry of an address
+func add(entries map[string]Entry, entry Entry) {
+	entry.Address = strings.ToLower(entry.Address)
+	if _, ok := entries[entry.Address]; !ok {
+		entries[entry.Address] = entry
+	}
+}
@@ -0,0 +1,271 @@
+package screening
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// Entry is a sanctioned address of a list
+type Entry struct {
+	Address  string // Lowercase address
+	Name     string // Sanctioned party
+	Programs []string
+}
+
+// list is a sanctions list loaded from a file
+type list struct {
+	name     string
+	path     string
+	entries  map[string]Entry
+	modTime  time.Time // Modification time of the file entries were loaded from
+	loadedAt time.Time
+	err      error // Why the last reload failed
+}
+
+// Exposure is an address reached by an analysis, with the transactions that reached it
+type Exposure struct {
+	Address  string
+	Hops     int
+	TxHashes []string
+}
+
+// Screener checks addresses against sanctions lists. Lists are reloaded when their
+// file changes, which is checked at most once per check interval. A nil Screener
+// screens against no lists.
+type Screener struct {
+	checkInterval time.Duration // 0 disables reloading on change
+
+	mu        sync.RWMutex
+	lists     []*list
+	lastCheck time.Time
+}
+
+// New loads the sanctions lists in specs, each a file path optionally preceded by the list
+// name and "=", e.g. "ofac=./sdn.xml". Without a name, the file name names the list.
+func New(specs []string, checkInterval time.Duration) (*Screener, error) {
+	s := &Screener{checkInterval: checkInterval, lastCheck: time.Now()}
+
+	names := map[string]bool{}
+	for _, spec := range specs {
+		l := &list{path: spec}
+		if name, path, ok := strings.Cut(spec, "="); ok {
+			l.name, l.path = strings.TrimSpace(name), strings.TrimSpace(path)
+		} else {
+			l.name = strings.TrimSuffix(filepath.Base(spec), filepath.Ext(spec))
+		}
+		if names[l.name] {
+			return nil, fmt.Errorf("duplicate sanctions list %q", l.name)
+		}
+		names[l.name] = true
+
+		if err := l.load(); err != nil {
+			return nil, err
+		}
+		s.lists = append(s.lists, l)
+	}
+
+	return s, nil
+}
+
+// load reads the entries of the list from its file, keeping the previous entries on failure
+func (l *list) load() error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		l.err = fmt.Errorf("failed to read sanctions list %s: %w", l.name, err)
+		return l.err
+	}
+
+	entries, err := ReadFile(l.path)
+	if err != nil {
+		l.err = fmt.Errorf("sanctions list %s: %w", l.name, err)
+		return l.err
+	}
+
+	l.entries = entries
+	l.modTime = info.ModTime()
+	l.loadedAt = time.Now()
+	l.err = nil
+	return nil
+}
+
+// Reload reloads every list from its file. A list that fails to load keeps its
+// previous addresses, and the failures are returned together.
+func (s *Screener) Reload() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for _, l := range s.lists {
+		if err := l.load(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.lastCheck = time.Now()
+	return errors.Join(errs...)
+}
+
+// refresh reloads the lists whose file changed since they were loaded,
+// once the check interval has passed since the last check
+func (s *Screener) refresh() {
+	if s.checkInterval <= 0 {
+		return
+	}
+
+	s.mu.RLock()
+	due := time.Since(s.lastCheck) >= s.checkInterval
+	s.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.lastCheck) < s.checkInterval {
+		return
+	}
+	s.lastCheck = time.Now()
+
+	for _, l := range s.lists {
+		info, err := os.Stat(l.path)
+		if err != nil || !info.ModTime().Equal(l.modTime) {
+			// Failures are reported in Status
+			l.load()
+		}
+	}
+}
+
+// Status describes every list
+func (s *Screener) Status() []models.SanctionsList {
+	if s == nil {
+		return []models.SanctionsList{}
+	}
+	s.refresh()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := make([]models.SanctionsList, 0, len(s.lists))
+	for _, l := range s.lists {
+		item := models.SanctionsList{
+			Name:       l.name,
+			Path:       l.path,
+			Addresses:  len(l.entries),
+			ModifiedAt: l.modTime,
+			LoadedAt:   l.loadedAt,
+		}
+		if l.err != nil {
+			item.Error = l.err.Error()
+		}
+		status = append(status, item)
+	}
+	return status
+}
+
+// Listed returns the names of the lists address is on
+func (s *Screener) Listed(address string) []string {
+	if s == nil {
+		return nil
+	}
+	s.refresh()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var names []string
+	for _, l := range s.lists {
+		if _, ok := l.entries[strings.ToLower(address)]; ok {
+			names = append(names, l.name)
+		}
+	}
+	return names
+}
+
+// Screen checks target and the addresses it was exposed to against every list. The target
+// is screened at hop 0. It returns nil if no lists are configured.
+func (s *Screener) Screen(target string, exposures []Exposure) *models.Screening {
+	if s == nil || len(s.lists) == 0 {
+		return nil
+	}
+	s.refresh()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	screening := &models.Screening{Matches: []models.ScreeningMatch{}}
+	for _, l := range s.lists {
+		screening.Lists = append(screening.Lists, l.name)
+	}
+
+	// Screen each address once, at its lowest hop distance
+	closest := map[string]*Exposure{strings.ToLower(target): {Address: target, TxHashes: []string{}}}
+	for _, exposure := range exposures {
+		address := strings.ToLower(exposure.Address)
+		c, ok := closest[address]
+		switch {
+		case !ok:
+			exposure.TxHashes = append([]string{}, exposure.TxHashes...)
+			closest[address] = &exposure
+		case exposure.Hops < c.Hops:
+			c.Hops, c.TxHashes = exposure.Hops, append([]string{}, exposure.TxHashes...)
+		case exposure.Hops == c.Hops:
+			c.TxHashes = append(c.TxHashes, exposure.TxHashes...)
+		}
+	}
+
+	for address, exposure := range closest {
+		for _, l := range s.lists {
+			entry, ok := l.entries[address]
+			if !ok {
+				continue
+			}
+			screening.Matches = append(screening.Matches, models.ScreeningMatch{
+				Address:  address,
+				List:     l.name,
+				Name:     entry.Name,
+				Programs: entry.Programs,
+				Hops:     exposure.Hops,
+				TxHashes: dedupe(exposure.TxHashes),
+			})
+		}
+	}
+
+	sort.Slice(screening.Matches, func(i, j int) bool {
+		a, b := screening.Matches[i], screening.Matches[j]
+		if a.Hops != b.Hops {
+			return a.Hops < b.Hops
+		}
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		return a.List < b.List
+	})
+	screening.Matched = len(screening.Matches) > 0
+
+	return screening
+}
+
+// dedupe drops repeated hashes, keeping the order they first appear in
+func dedupe(hashes []string) []string {
+	unique := make([]string, 0, len(hashes))
+	seen := map[string]bool{}
+	for _, hash := range hashes {
+		if !seen[hash] {
+			seen[hash] = true
+			unique = append(unique, hash)
+		}
+	}
+	return unique
+}
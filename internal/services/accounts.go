@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/client"
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// maxPrecompile is the highest precompiled contract address, the BLS12-381 map-to-G2 precompile
+var maxPrecompile = big.NewInt(0x11)
+
+// isPrecompile reports whether address is a precompiled contract, 0x…01 to maxPrecompile
+func isPrecompile(address string) bool {
+	n, ok := new(big.Int).SetString(strings.TrimPrefix(strings.ToLower(address), "0x"), 16)
+	return ok && n.Sign() > 0 && n.Cmp(maxPrecompile) <= 0
+}
+
+// contractCreations looks up the creations of the contracts among addresses through the named source,
+// with apiKey overriding its API key if set
+func (s *AnalysisService) contractCreations(ctx context.Context, sourceName, apiKey string, chainId int, addresses []string) (map[string]models.ContractCreation, error) {
+	source, err := s.sources.Get(sourceName)
+	if err != nil {
+		return nil, err
+	}
+	resolver, ok := source.(client.ContractResolver)
+	if !ok {
+		return nil, fmt.Errorf("source cannot look up contract creations: %w", errors.ErrUnsupported)
+	}
+	return resolver.ContractCreations(ctx, chainId, apiKey, addresses)
+}
+
+// ClassifyAccounts determines the account type of the counterparties of target, given per chain,
+// keyed by lowercase address. An address classified on several chains gets the type of highest
+// precedence in models.AccountTypes. apiKey overrides the API key of the source if set.
+func (s *AnalysisService) ClassifyAccounts(ctx context.Context, sourceName, apiKey, target string, addresses map[int][]string) (map[string]string, error) {
+	target = strings.ToLower(target)
+	types := map[string]string{}
+	classify := func(address, accountType string) {
+		if current, ok := types[address]; !ok || slices.Index(models.AccountTypes, accountType) < slices.Index(models.AccountTypes, current) {
+			types[address] = accountType
+		}
+	}
+
+	for chainId, chainAddresses := range addresses {
+		// Precompiles need no lookup
+		var lookup []string
+		for _, address := range chainAddresses {
+			address = strings.ToLower(address)
+			switch {
+			case address == "":
+				// No address to look up
+			case isPrecompile(address):
+				classify(address, models.AccountPrecompile)
+			default:
+				lookup = append(lookup, address)
+			}
+		}
+		if len(lookup) == 0 {
+			continue
+		}
+
+		creations, err := s.contractCreations(ctx, sourceName, apiKey, chainId, lookup)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range lookup {
+			creation, ok := creations[address]
+			switch {
+			case !ok:
+				classify(address, models.AccountEOA)
+			case strings.EqualFold(creation.ContractCreator, target):
+				classify(address, models.AccountCreatedByTarget)
+			default:
+				classify(address, models.AccountContract)
+			}
+		}
+	}
+
+	return types, nil
+}
@@ -1,15 +1,21 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"Ethereum-fund-flow-analysis/internal/chains"
 	"Ethereum-fund-flow-analysis/internal/client"
 	"Ethereum-fund-flow-analysis/internal/models"
 )
 
 // AnalysisService handles the transaction analysis logic
 type AnalysisService struct {
-	etherscanClient *client.Client
+	sources          *client.SourceRegistry
+	chains           *chains.Registry
+	chainConcurrency int // Chains of a cross-chain analysis fetched concurrently
 }
 
 // AnalysisParams contains parameters for the analysis
@@ -22,37 +28,146 @@ type AnalysisParams struct {
 	Offset     int
 	Sort       string
   ApiKey     string
+	Source     string    // Name of the transaction source, empty for the default
+	Exhaustive bool      // Retrieve every transaction in the block range instead of a single page
+	From       time.Time // Narrows the block range to blocks mined at or after this time, zero for no bound
+	To         time.Time // Narrows the block range to blocks mined at or before this time, zero for no bound
+	ChainIds   []int     // Chains of a cross-chain analysis, empty to analyze ChainId alone
+	AllChains  bool      // Analyze every chain in the chain registry
+	Partial    bool      // Go on without the transaction types that fail, reporting them as warnings
+}
+
+// crossChain reports whether params span several chains
+func (p AnalysisParams) crossChain() bool {
+	return p.AllChains || len(p.ChainIds) > 1
+}
+
+// requestParams converts analysis params to Etherscan request params
+func (p AnalysisParams) requestParams() client.EtherscanRequestParams {
+	return client.EtherscanRequestParams{
+		Address:    p.Address,
+		ChainId:    p.ChainId,
+		StartBlock: p.StartBlock,
+		EndBlock:   p.EndBlock,
+		Page:       p.Page,
+		Offset:     p.Offset,
+		Sort:       p.Sort,
+		ApiKey:     p.ApiKey,
+		Exhaustive: p.Exhaustive,
+	}
 }
 
 // NewAnalysisService creates a new analysis service
-func NewAnalysisService(etherscanClient *client.Client) *AnalysisService {
+func NewAnalysisService(sources *client.SourceRegistry, chainRegistry *chains.Registry, chainConcurrency int) *AnalysisService {
 	return &AnalysisService{
-		etherscanClient: etherscanClient,
+		sources:          sources,
+		chains:           chainRegistry,
+		chainConcurrency: chainConcurrency,
 	}
 }
 
-// AnalyzeBeneficiaries analyzes transactions to identify beneficiaries
-func (s *AnalysisService) AnalyzeBeneficiaries(params AnalysisParams) ([]models.Beneficiary, error) {
-	// Convert to Etherscan request params
-	requestParams := client.EtherscanRequestParams{
-		Address:    params.Address,
-    ChainId:    params.ChainId,
-		StartBlock: params.StartBlock,
-		EndBlock:   params.EndBlock,
-		Page:       params.Page,
-		Offset:     params.Offset,
-		Sort:       params.Sort,
-    ApiKey:     params.ApiKey,
+// errSingleChain is returned by analyses that cover a single chain when given several
+var errSingleChain = fmt.Errorf("only beneficiary and payer analyses can span several chains: %w", errors.ErrUnsupported)
+
+// chain looks up the requested chain in the registry
+func (s *AnalysisService) chain(chainId int) (models.Chain, error) {
+	chain, ok := s.chains.Get(chainId)
+	if !ok {
+		return chain, fmt.Errorf("chain %d is not in the chain registry: %w", chainId, client.ErrInvalidChain)
+	}
+	return chain, nil
+}
+
+// fetchTransactions fetches all transaction types for params from the requested source
+func (s *AnalysisService) fetchTransactions(ctx context.Context, params AnalysisParams) (TransactionCollection, error) {
+	if params.crossChain() {
+		return TransactionCollection{}, errSingleChain
+	}
+
+	chain, err := s.chain(params.ChainId)
+	if err != nil {
+		return TransactionCollection{}, err
+	}
+
+	source, err := s.sources.Get(params.Source)
+	if err != nil {
+		return TransactionCollection{}, err
+	}
+
+	ctx, cacheStats := client.WithCacheStats(ctx)
+
+	params, err = resolveBlockRange(ctx, source, params)
+	if err != nil {
+		return TransactionCollection{}, err
+	}
+
+	txCollection, err := FetchAllTransactions(ctx, source, params.requestParams(), params.Partial)
+	txCollection.Cache = cacheStats.Status()
+	txCollection.ChainId = chain.ID
+	txCollection.Native = nativeAsset(chain)
+	if err != nil {
+		return txCollection, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	return txCollection, nil
+}
+
+// resolveBlockRange narrows the block range of params to the blocks mined between
+// params.From and params.To. An upper bound in the future leaves the range open.
+func resolveBlockRange(ctx context.Context, source client.TransactionSource, params AnalysisParams) (AnalysisParams, error) {
+	if params.From.IsZero() && params.To.IsZero() {
+		return params, nil
+	}
+
+	resolver, ok := source.(client.BlockResolver)
+	if !ok {
+		return params, fmt.Errorf("failed to resolve date range: source cannot resolve dates to blocks: %w", errors.ErrUnsupported)
+	}
+
+	if !params.From.IsZero() {
+		block, err := resolver.BlockByTime(ctx, params.ChainId, params.ApiKey, params.From, client.ClosestAfter)
+		if err != nil {
+			return params, fmt.Errorf("failed to resolve date range: %w", err)
+		}
+		params.StartBlock = max(params.StartBlock, block)
+	}
+
+	if !params.To.IsZero() && params.To.Before(time.Now()) {
+		block, err := resolver.BlockByTime(ctx, params.ChainId, params.ApiKey, params.To, client.ClosestBefore)
+		if err != nil {
+			return params, fmt.Errorf("failed to resolve date range: %w", err)
+		}
+		if params.EndBlock < 0 || block < params.EndBlock {
+			params.EndBlock = block
+		}
+	}
+
+	return params, nil
+}
+
+// analyzeCounterparties fetches the transactions of params.Address, from several chains for a
+// cross-chain analysis, and aggregates them per counterparty in the requested direction
+func (s *AnalysisService) analyzeCounterparties(ctx context.Context, params AnalysisParams, isOutgoing bool) (map[string]*models.EntityWithTransactions, models.FetchStatus, error) {
+	if params.crossChain() {
+		return s.analyzeAcrossChains(ctx, params, isOutgoing)
 	}
 
 	// Fetch all transactions concurrently
-	txCollection, err := FetchAllTransactions(s.etherscanClient, requestParams)
+	txCollection, err := s.fetchTransactions(ctx, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+		return nil, models.FetchStatus{}, err
 	}
 
-	// Process transactions to find beneficiaries (outgoing = true)
-	beneficiaryMap := ProcessTransactions(params.Address, txCollection, true)
+	return ProcessTransactions(params.Address, txCollection, isOutgoing), txCollection.Status(), nil
+}
+
+// AnalyzeBeneficiaries analyzes transactions to identify beneficiaries
+func (s *AnalysisService) AnalyzeBeneficiaries(ctx context.Context, params AnalysisParams) ([]models.Beneficiary, models.FetchStatus, error) {
+	// Find beneficiaries (outgoing = true)
+	beneficiaryMap, status, err := s.analyzeCounterparties(ctx, params, true)
+	if err != nil {
+		return nil, models.FetchStatus{}, err
+	}
 
 	// Convert map to slice
 	beneficiaries := make([]models.Beneficiary, 0, len(beneficiaryMap))
@@ -60,44 +175,36 @@ func (s *AnalysisService) AnalyzeBeneficiaries(params AnalysisParams) ([]models.
 		beneficiaries = append(beneficiaries, models.Beneficiary{
 			Address:      ben.Address,
 			Amount:       ben.Amount,
+			Assets:       ben.Assets,
+			Chains:       ben.Chains,
+			Created:      ben.Created,
 			Transactions: ben.Transactions,
 		})
 	}
 
-	return beneficiaries, nil
+	return beneficiaries, status, nil
 }
 
 // AnalyzePayers analyzes incoming transactions to identify payers
-func (s *AnalysisService) AnalyzePayers(params AnalysisParams) ([]models.Payer, error) {
-	// Convert to Etherscan request params
-	requestParams := client.EtherscanRequestParams{
-		Address:    params.Address,
-		StartBlock: params.StartBlock,
-		EndBlock:   params.EndBlock,
-		Page:       params.Page,
-		Offset:     params.Offset,
-		Sort:       params.Sort,
-    ApiKey:     params.ApiKey,
-	}
-
-	// Fetch all transactions concurrently
-	txCollection, err := FetchAllTransactions(s.etherscanClient, requestParams)
+func (s *AnalysisService) AnalyzePayers(ctx context.Context, params AnalysisParams) ([]models.Payer, models.FetchStatus, error) {
+	// Find payers (outgoing = false)
+	payerMap, status, err := s.analyzeCounterparties(ctx, params, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+		return nil, models.FetchStatus{}, err
 	}
 
-	// Process transactions to find payers (outgoing = false)
-	payerMap := ProcessTransactions(params.Address, txCollection, false)
-
 	// Convert map to slice
 	payers := make([]models.Payer, 0, len(payerMap))
 	for _, p := range payerMap {
 		payers = append(payers, models.Payer{
 			Address:      p.Address,
 			Amount:       p.Amount,
+			Assets:       p.Assets,
+			Chains:       p.Chains,
+			Created:      p.Created,
 			Transactions: p.Transactions,
 		})
 	}
 
-	return payers, nil
+	return payers, status, nil
 }
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/utils"
+)
+
+// AnalyzeCounterparties combines the payer and beneficiary analyses of an address into the
+// inflow, outflow and net flow of every counterparty, from a single fetch of its transactions
+func (s *AnalysisService) AnalyzeCounterparties(ctx context.Context, params AnalysisParams) ([]models.Counterparty, models.FetchStatus, error) {
+	// Fetch all transactions concurrently
+	txCollection, err := s.fetchTransactions(ctx, params)
+	if err != nil {
+		return nil, models.FetchStatus{}, err
+	}
+
+	counterpartyMap := map[string]*models.Counterparty{}
+	mergeFlows(counterpartyMap, ProcessTransactions(params.Address, txCollection, false), txCollection.Native, true)
+	mergeFlows(counterpartyMap, ProcessTransactions(params.Address, txCollection, true), txCollection.Native, false)
+
+	// Convert map to slice, deriving the net flows and native totals
+	counterparties := make([]models.Counterparty, 0, len(counterpartyMap))
+	for _, c := range counterpartyMap {
+		for _, flow := range c.Assets {
+			flow.Net = flow.Inflow.Sub(flow.Outflow)
+		}
+		if native, ok := c.Assets[models.NativeAsset]; ok {
+			c.Inflow, c.Outflow, c.Net = native.Inflow, native.Outflow, native.Net
+		}
+		counterparties = append(counterparties, *c)
+	}
+
+	return counterparties, txCollection.Status(), nil
+}
+
+// mergeFlows adds the per-asset amounts of one direction to the counterparties
+func mergeFlows(counterpartyMap map[string]*models.Counterparty, entityMap map[string]*models.EntityWithTransactions, native models.Asset, incoming bool) {
+	for address, entity := range entityMap {
+		c, exists := counterpartyMap[address]
+		if !exists {
+			c = &models.Counterparty{
+				Address: entity.Address,
+				Inflow:  utils.NewAmount(nil, native.Decimals),
+				Outflow: utils.NewAmount(nil, native.Decimals),
+				Net:     utils.NewAmount(nil, native.Decimals),
+				Assets:  map[string]*models.AssetFlow{},
+			}
+			counterpartyMap[address] = c
+		}
+		for _, tx := range entity.Transactions {
+			c.TxHashes = append(c.TxHashes, tx.TransactionID)
+		}
+
+		for key, a := range entity.Assets {
+			flow, exists := c.Assets[key]
+			if !exists {
+				flow = &models.AssetFlow{
+					Asset:   a.Asset,
+					Inflow:  utils.NewAmount(nil, a.Decimals),
+					Outflow: utils.NewAmount(nil, a.Decimals),
+				}
+				c.Assets[key] = flow
+			}
+
+			if incoming {
+				flow.Inflow = flow.Inflow.Add(a.Amount)
+				flow.InCount += a.TxCount
+				c.InCount += a.TxCount
+			} else {
+				flow.Outflow = flow.Outflow.Add(a.Amount)
+				flow.OutCount += a.TxCount
+				c.OutCount += a.TxCount
+			}
+
+			flow.FirstTimestamp = earliest(flow.FirstTimestamp, a.FirstTimestamp)
+			flow.LastTimestamp = max(flow.LastTimestamp, a.LastTimestamp)
+			c.FirstTimestamp = earliest(c.FirstTimestamp, a.FirstTimestamp)
+			c.LastTimestamp = max(c.LastTimestamp, a.LastTimestamp)
+		}
+	}
+}
+
+// earliest returns the earlier of two timestamps, where zero means not set
+func earliest(a, b int64) int64 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -16,17 +18,39 @@ type TransactionCollection struct {
 	ERC721Txs   []models.ERC721Transfer
 	ERC1155Txs  []models.ERC1155Transfer
 	Errors      []error
+	Truncated   []string            // Names of the transaction types that may be missing records
+	Cache       *models.CacheStatus // Cache hits and misses, nil if no cache was involved
+	ChainId     int                 // Chain the transactions were fetched from
+	Native      models.Asset        // Native coin of that chain
+	Warnings    []models.Warning    // Transaction types that failed and were left out in partial mode
+
+	emptyLists int // Number of transaction types the source reported no transactions for
+}
+
+// Status reports whether every transaction list was retrieved completely
+func (c TransactionCollection) Status() models.FetchStatus {
+	return models.FetchStatus{
+		Complete:  len(c.Truncated) == 0 && len(c.Warnings) == 0,
+		Truncated: c.Truncated,
+		Cache:     c.Cache,
+		Warnings:  c.Warnings,
+	}
 }
 
 // FetchTask defines a generic transaction fetch operation
 type FetchTask[T any] struct {
-	Name     string                                                  // Name of the transaction type for error reporting
-	Fetcher  func(params client.EtherscanRequestParams) ([]T, error) // Function to fetch transactions
-	Assigner func(collection *TransactionCollection, result []T)     // Function to assign results to the collection
+	Name     string                                                                       // Name of the transaction type for error reporting
+	Fetcher  func(ctx context.Context, params client.EtherscanRequestParams) ([]T, error) // Function to fetch transactions
+	Assigner func(collection *TransactionCollection, result []T)                          // Function to assign results to the collection
 }
 
-// FetchAllTransactions concurrently fetches all transaction types for an address
-func FetchAllTransactions(ethClient *client.Client, params client.EtherscanRequestParams) (TransactionCollection, error) {
+// FetchAllTransactions concurrently fetches all transaction types for an address.
+// The first task to fail cancels the others, unless partial is set: then the failed
+// types are reported as warnings and only fail the fetch if no other type was retrieved.
+func FetchAllTransactions(ctx context.Context, source client.TransactionSource, params client.EtherscanRequestParams, partial bool) (TransactionCollection, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	result := TransactionCollection{
@@ -38,7 +62,7 @@ func FetchAllTransactions(ethClient *client.Client, params client.EtherscanReque
 		// Normal transactions task
 		FetchTask[models.NormalTx]{
 			Name:    "normal transactions",
-			Fetcher: ethClient.GetNormalTransactions,
+			Fetcher: source.GetNormalTransactions,
 			Assigner: func(collection *TransactionCollection, txs []models.NormalTx) {
 				collection.NormalTxs = txs
 			},
@@ -46,7 +70,7 @@ func FetchAllTransactions(ethClient *client.Client, params client.EtherscanReque
 		// Internal transactions task
 		FetchTask[models.InternalTx]{
 			Name:    "internal transactions",
-			Fetcher: ethClient.GetInternalTransactions,
+			Fetcher: source.GetInternalTransactions,
 			Assigner: func(collection *TransactionCollection, txs []models.InternalTx) {
 				collection.InternalTxs = txs
 			},
@@ -54,7 +78,7 @@ func FetchAllTransactions(ethClient *client.Client, params client.EtherscanReque
 		// ERC20 transfers task
 		FetchTask[models.ERC20Transfer]{
 			Name:    "ERC20 transfers",
-			Fetcher: ethClient.GetERC20Transfers,
+			Fetcher: source.GetERC20Transfers,
 			Assigner: func(collection *TransactionCollection, txs []models.ERC20Transfer) {
 				collection.ERC20Txs = txs
 			},
@@ -62,7 +86,7 @@ func FetchAllTransactions(ethClient *client.Client, params client.EtherscanReque
 		// ERC721 transfers task
 		FetchTask[models.ERC721Transfer]{
 			Name:    "ERC721 transfers",
-			Fetcher: ethClient.GetERC721Transfers,
+			Fetcher: source.GetERC721Transfers,
 			Assigner: func(collection *TransactionCollection, txs []models.ERC721Transfer) {
 				collection.ERC721Txs = txs
 			},
@@ -70,7 +94,7 @@ func FetchAllTransactions(ethClient *client.Client, params client.EtherscanReque
 		// ERC1155 transfers task
 		FetchTask[models.ERC1155Transfer]{
 			Name:    "ERC1155 transfers",
-			Fetcher: ethClient.GetERC1155Transfers,
+			Fetcher: source.GetERC1155Transfers,
 			Assigner: func(collection *TransactionCollection, txs []models.ERC1155Transfer) {
 				collection.ERC1155Txs = txs
 			},
@@ -84,24 +108,33 @@ func FetchAllTransactions(ethClient *client.Client, params client.EtherscanReque
 		// Use type assertions to handle different transaction types
 		switch t := task.(type) {
 		case FetchTask[models.NormalTx]:
-			go executeTask(&wg, &mu, &result, params, t)
+			go executeTask(ctx, cancel, &wg, &mu, &result, params, partial, t)
 		case FetchTask[models.InternalTx]:
-			go executeTask(&wg, &mu, &result, params, t)
+			go executeTask(ctx, cancel, &wg, &mu, &result, params, partial, t)
 		case FetchTask[models.ERC20Transfer]:
-			go executeTask(&wg, &mu, &result, params, t)
+			go executeTask(ctx, cancel, &wg, &mu, &result, params, partial, t)
 		case FetchTask[models.ERC721Transfer]:
-			go executeTask(&wg, &mu, &result, params, t)
+			go executeTask(ctx, cancel, &wg, &mu, &result, params, partial, t)
 		case FetchTask[models.ERC1155Transfer]:
-			go executeTask(&wg, &mu, &result, params, t)
+			go executeTask(ctx, cancel, &wg, &mu, &result, params, partial, t)
 		}
 	}
 
 	// Wait for all goroutines to complete
 	wg.Wait()
 
-	// Check if there were any errors
+	// Check if there were any errors. A partial fetch goes on without the failed types
+	// as long as it was not cancelled and another type could be retrieved.
 	if len(result.Errors) > 0 {
-		return result, fmt.Errorf("failed to fetch some transactions: %v", result.Errors)
+		retrieved := len(fetchTasks) - len(result.Errors) - result.emptyLists
+		if !partial || ctx.Err() != nil || retrieved == 0 {
+			return result, fmt.Errorf("failed to fetch some transactions: %w", errors.Join(result.Errors...))
+		}
+	}
+
+	// An address without any activity is reported as such
+	if result.emptyLists == len(fetchTasks) {
+		return result, fmt.Errorf("%s: %w", params.Address, client.ErrNoTransactions)
 	}
 
 	return result, nil
@@ -109,23 +142,51 @@ func FetchAllTransactions(ethClient *client.Client, params client.EtherscanReque
 
 // executeTask runs a fetch task and safely updates the result collection
 func executeTask[T any](
+	ctx context.Context,
+	cancel context.CancelFunc,
 	wg *sync.WaitGroup,
 	mu *sync.Mutex,
 	result *TransactionCollection,
 	params client.EtherscanRequestParams,
+	partial bool,
 	task FetchTask[T],
 ) {
 	defer wg.Done()
 
 	// Execute the fetch operation
-	txs, err := task.Fetcher(params)
+	txs, err := task.Fetcher(ctx, params)
 
 	// Safely update the result collection
 	mu.Lock()
 	defer mu.Unlock()
 
+	// A transaction type without records is not a failure
+	if errors.Is(err, client.ErrNoTransactions) {
+		result.emptyLists++
+		return
+	}
+
+	// A truncated walk still returns every transaction it could retrieve
+	if errors.Is(err, client.ErrTruncated) {
+		result.Truncated = append(result.Truncated, task.Name)
+		err = nil
+	} else if err == nil && !params.Exhaustive && params.Offset > 0 && len(txs) >= params.Offset {
+		// A full page means more pages may exist
+		result.Truncated = append(result.Truncated, task.Name)
+	}
+
 	if err != nil {
+		// Tasks cancelled because of an earlier failure add nothing to it
+		if len(result.Errors) > 0 && errors.Is(err, context.Canceled) {
+			return
+		}
+
 		result.Errors = append(result.Errors, fmt.Errorf("%s: %w", task.Name, err))
+		if partial {
+			result.Warnings = append(result.Warnings, models.Warning{TransactionType: task.Name, Error: err.Error()})
+			return
+		}
+		cancel()
 		return
 	}
 
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/client"
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/utils"
+)
+
+// StreamLedger walks every transaction list of params.Address over the requested block range,
+// one list after the other in ascending block order, and passes each batch of ledger entries
+// to emit as soon as it is retrieved. Page, offset and sort order are ignored. In partial mode,
+// a list that fails is reported as a warning and the walk goes on with the next one.
+func (s *AnalysisService) StreamLedger(ctx context.Context, params AnalysisParams, emit func(entries []models.LedgerEntry) error) (models.FetchStatus, error) {
+	status := models.FetchStatus{Complete: true}
+
+	if params.crossChain() {
+		return status, errSingleChain
+	}
+
+	chain, err := s.chain(params.ChainId)
+	if err != nil {
+		return status, err
+	}
+	native := nativeAsset(chain)
+
+	source, err := s.sources.Get(params.Source)
+	if err != nil {
+		return status, err
+	}
+
+	params, err = resolveBlockRange(ctx, source, params)
+	if err != nil {
+		return status, err
+	}
+
+	requestParams := params.requestParams()
+	address := strings.ToLower(params.Address)
+
+	lists := []struct {
+		name string
+		walk func() (bool, error)
+	}{
+		{"normal transactions", func() (bool, error) {
+			return walkLedger(ctx, source.GetNormalTransactions, requestParams, address, func(tx models.NormalTx) Transfer {
+				return normalTransfer(tx, native)
+			}, emit)
+		}},
+		{"internal transactions", func() (bool, error) {
+			return walkLedger(ctx, source.GetInternalTransactions, requestParams, address, func(tx models.InternalTx) Transfer {
+				return internalTransfer(tx, native)
+			}, emit)
+		}},
+		{"ERC20 transfers", func() (bool, error) {
+			return walkLedger(ctx, source.GetERC20Transfers, requestParams, address, erc20Transfer, emit)
+		}},
+		{"ERC721 transfers", func() (bool, error) {
+			return walkLedger(ctx, source.GetERC721Transfers, requestParams, address, erc721Transfer, emit)
+		}},
+		{"ERC1155 transfers", func() (bool, error) {
+			return walkLedger(ctx, source.GetERC1155Transfers, requestParams, address, erc1155Transfer, emit)
+		}},
+	}
+
+	for _, list := range lists {
+		truncated, err := list.walk()
+		if truncated {
+			status.Complete = false
+			status.Truncated = append(status.Truncated, list.name)
+		}
+		if err != nil && params.Partial && ctx.Err() == nil {
+			status.Complete = false
+			status.Warnings = append(status.Warnings, models.Warning{TransactionType: list.name, Error: err.Error()})
+			continue
+		}
+		if err != nil {
+			return status, fmt.Errorf("failed to stream %s: %w", list.name, err)
+		}
+	}
+
+	return status, nil
+}
+
+// walkLedger walks one transaction list, converting every batch into ledger entries
+func walkLedger[T interface{ Block() int }](
+	ctx context.Context,
+	fetch client.PageFetcher[T],
+	params client.EtherscanRequestParams,
+	address string,
+	convert func(T) Transfer,
+	emit func(entries []models.LedgerEntry) error,
+) (bool, error) {
+	return client.WalkBlockRange(ctx, fetch, params, func(batch []T, _ int64, _ bool) error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		entries := make([]models.LedgerEntry, 0, len(batch))
+		for _, tx := range batch {
+			if entry, ok := newLedgerEntry(address, convert(tx)); ok {
+				entries = append(entries, entry)
+			}
+		}
+		return emit(entries)
+	})
+}
+
+// newLedgerEntry describes a transfer relative to address,
+// reporting false if the transfer does not touch it
+func newLedgerEntry(address string, transfer Transfer) (models.LedgerEntry, bool) {
+	entry := models.LedgerEntry{
+		Type:      transfer.Type,
+		Kind:      transfer.Kind,
+		Hash:      transfer.Hash,
+		Block:     transfer.Block,
+		Timestamp: transfer.Timestamp,
+		DateTime:  utils.FormatTimestamp(transfer.Timestamp),
+		From:      strings.ToLower(transfer.From),
+		To:        strings.ToLower(transfer.To),
+		Asset:     transfer.Asset.Key,
+		Symbol:    transfer.Asset.Symbol,
+		TokenID:   transfer.TokenID,
+		Value:     transfer.Value.String(),
+		Decimals:  transfer.Asset.Decimals,
+		GasUsed:   transfer.GasUsed,
+		Status:    "success",
+	}
+	if transfer.GasPrice != nil {
+		entry.GasPrice = transfer.GasPrice.Int().String()
+	}
+	if transfer.Failed {
+		entry.Status = "failed"
+	}
+
+	switch {
+	case entry.From == address && entry.To == address:
+		entry.Direction, entry.Counterparty = "self", address
+	case entry.From == address:
+		entry.Direction, entry.Counterparty = "out", entry.To
+	case entry.To == address:
+		entry.Direction, entry.Counterparty = "in", entry.From
+	default:
+		return entry, false
+	}
+
+	return entry, true
+}
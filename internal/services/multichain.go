@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"Ethereum-fund-flow-analysis/internal/client"
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/utils"
+)
+
+// chainOutcome is the result of analyzing one chain of a cross-chain analysis
+type chainOutcome struct {
+	chain    models.Chain
+	entities map[string]*models.EntityWithTransactions
+	status   models.ChainStatus
+	err      error            // Why the chain failed, nil if it did not
+	warnings []models.Warning // Transaction types left out in partial mode
+}
+
+// chainAssetKey qualifies an asset key with its chain, since the same key
+// (the native coin or a contract address) names different assets on different chains
+func chainAssetKey(chainId int, asset string) string {
+	return fmt.Sprintf("%d:%s", chainId, asset)
+}
+
+// crossChains returns the chains a cross-chain analysis covers, in the requested order
+func (s *AnalysisService) crossChains(params AnalysisParams) ([]models.Chain, error) {
+	if params.AllChains {
+		// Testnets are only analyzed when requested by ID
+		var chains []models.Chain
+		for _, chain := range s.chains.List() {
+			if !chain.Testnet {
+				chains = append(chains, chain)
+			}
+		}
+		return chains, nil
+	}
+
+	chains := make([]models.Chain, 0, len(params.ChainIds))
+	seen := map[int]bool{}
+	for _, id := range params.ChainIds {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		chain, err := s.chain(id)
+		if err != nil {
+			return nil, err
+		}
+		chains = append(chains, chain)
+	}
+
+	return chains, nil
+}
+
+// analyzeAcrossChains aggregates the counterparties of params.Address on every requested chain,
+// fetching at most s.chainConcurrency chains concurrently. A chain that fails, or is not reached
+// before the deadline, is reported in the status rather than failing the analysis, unless every
+// chain fails.
+func (s *AnalysisService) analyzeAcrossChains(ctx context.Context, params AnalysisParams, isOutgoing bool) (map[string]*models.EntityWithTransactions, models.FetchStatus, error) {
+	chains, err := s.crossChains(params)
+	if err != nil {
+		return nil, models.FetchStatus{}, err
+	}
+	// For instance chainid=all with a registry of testnets only
+	if len(chains) == 0 {
+		return nil, models.FetchStatus{}, fmt.Errorf("no chain selected: %w", client.ErrInvalidChain)
+	}
+
+	// Share cache statistics across every chain
+	ctx, cacheStats := client.WithCacheStats(ctx)
+
+	var wg sync.WaitGroup
+	outcomes := make([]chainOutcome, len(chains))
+	slots := make(chan struct{}, max(s.chainConcurrency, 1))
+	for i, chain := range chains {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				outcomes[i] = chainOutcome{
+					chain:  chain,
+					status: models.ChainStatus{ChainId: chain.ID, Name: chain.Name, Error: ctx.Err().Error()},
+					err:    ctx.Err(),
+				}
+				return
+			}
+
+			chainParams := params
+			chainParams.ChainId, chainParams.ChainIds, chainParams.AllChains = chain.ID, nil, false
+			outcomes[i] = s.analyzeChain(ctx, chain, chainParams, isOutgoing)
+		}()
+	}
+	wg.Wait()
+
+	status := models.FetchStatus{Complete: true, Cache: cacheStats.Status()}
+	var errs []error
+	for _, outcome := range outcomes {
+		status.Chains = append(status.Chains, outcome.status)
+		if !outcome.status.Complete {
+			status.Complete = false
+		}
+		for _, name := range outcome.status.Truncated {
+			status.Truncated = append(status.Truncated, fmt.Sprintf("%d %s", outcome.chain.ID, name))
+		}
+		for _, warning := range outcome.warnings {
+			warning.ChainId = outcome.chain.ID
+			status.Warnings = append(status.Warnings, warning)
+		}
+		if outcome.err != nil {
+			errs = append(errs, fmt.Errorf("chain %d: %w", outcome.chain.ID, outcome.err))
+		}
+	}
+
+	if len(errs) == len(chains) {
+		return nil, status, fmt.Errorf("every chain failed: %w", errors.Join(errs...))
+	}
+
+	merged := mergeChains(chains[0], outcomes)
+	if len(merged) == 0 && len(errs) == 0 {
+		return nil, status, fmt.Errorf("%s on any chain: %w", params.Address, client.ErrNoTransactions)
+	}
+
+	return merged, status, nil
+}
+
+// analyzeChain aggregates the counterparties of one chain of a cross-chain analysis
+func (s *AnalysisService) analyzeChain(ctx context.Context, chain models.Chain, params AnalysisParams, isOutgoing bool) chainOutcome {
+	outcome := chainOutcome{
+		chain:  chain,
+		status: models.ChainStatus{ChainId: chain.ID, Name: chain.Name, Complete: true},
+	}
+
+	txCollection, err := s.fetchTransactions(ctx, params)
+	switch {
+	case errors.Is(err, client.ErrNoTransactions):
+		// A chain the address never used contributes nothing
+		return outcome
+	case err != nil:
+		outcome.status.Complete = false
+		outcome.status.Error = err.Error()
+		outcome.err = err
+		return outcome
+	}
+
+	outcome.status.Complete = len(txCollection.Truncated) == 0 && len(txCollection.Warnings) == 0
+	outcome.status.Truncated = txCollection.Truncated
+	outcome.warnings = txCollection.Warnings
+	outcome.entities = ProcessTransactions(params.Address, txCollection, isOutgoing)
+	return outcome
+}
+
+// mergeChains combines the counterparties found on every chain. Assets are keyed by chainAssetKey.
+// The native amount of a counterparty adds up the chains whose native coin matches that of primary,
+// counting testnets only if primary is one. The native amount on each chain is kept in its
+// per-chain subtotal.
+func mergeChains(primary models.Chain, outcomes []chainOutcome) map[string]*models.EntityWithTransactions {
+	merged := make(map[string]*models.EntityWithTransactions)
+
+	for _, outcome := range outcomes {
+		sameNative := outcome.chain.Native.Symbol == primary.Native.Symbol &&
+			outcome.chain.Native.Decimals == primary.Native.Decimals &&
+			outcome.chain.Testnet == primary.Testnet
+
+		for address, entity := range outcome.entities {
+			m, exists := merged[address]
+			if !exists {
+				m = &models.EntityWithTransactions{
+					Address:      entity.Address,
+					Amount:       utils.NewAmount(nil, primary.Native.Decimals),
+					Assets:       map[string]*models.AssetAmount{},
+					Chains:       map[int]*models.ChainSubtotal{},
+					Transactions: []models.Transaction{},
+				}
+				merged[address] = m
+			}
+
+			if sameNative {
+				m.Amount = m.Amount.Add(entity.Amount)
+			}
+			m.Created = m.Created || entity.Created
+			m.Chains[outcome.chain.ID] = &models.ChainSubtotal{
+				Symbol:  outcome.chain.Native.Symbol,
+				Amount:  entity.Amount,
+				TxCount: len(entity.Transactions),
+			}
+			for key, a := range entity.Assets {
+				a.ChainId = outcome.chain.ID
+				m.Assets[chainAssetKey(outcome.chain.ID, key)] = a
+			}
+			m.Transactions = append(m.Transactions, entity.Transactions...)
+		}
+	}
+
+	return merged
+}
@@ -1,8 +1,6 @@
 package service
 
 import (
-	"strings"
-
 	"Ethereum-fund-flow-analysis/internal/models"
 	"Ethereum-fund-flow-analysis/internal/utils"
 )
@@ -12,214 +10,66 @@ func ProcessTransactions(address string, txCollection TransactionCollection, isO
 	// This map will hold either beneficiaries or payers
 	entityMap := make(map[string]*models.EntityWithTransactions)
 
-	// Process normal transactions
-	for _, tx := range txCollection.NormalTxs {
+	for _, transfer := range CollectTransfers(txCollection) {
 		// Skip failed transactions
-		if tx.IsError == 1 {
+		if transfer.Failed {
 			continue
 		}
 
-		var counterpartyAddress string
-		if isOutgoing {
-			// Only consider outgoing transactions from address
-			if !strings.EqualFold(tx.From, address) {
-				continue
-			}
-			counterpartyAddress = tx.To
-		} else {
-			// Only consider incoming transactions to address
-			if !strings.EqualFold(tx.To, address) {
-				continue
-			}
-			counterpartyAddress = tx.From
+		// Only consider transfers in the requested direction
+		counterpartyAddress, ok := transfer.Counterparty(address, isOutgoing)
+		if !ok {
+			continue
 		}
 
-		// Convert value
-		amount := utils.ConvertWeiToEther(tx.Value.String())
+		amount := transfer.Amount()
 
 		// Create transaction record
-		timestamp := tx.TimeStamp.Time().Unix()
-		dateTime := utils.FormatTimestamp(timestamp)
-
 		transaction := models.Transaction{
 			TxAmount:      amount,
-			DateTime:      dateTime,
-			TransactionID: tx.Hash,
+			DateTime:      utils.FormatTimestamp(transfer.Timestamp),
+			Timestamp:     transfer.Timestamp,
+			TransactionID: transfer.Hash,
+			Type:          transfer.Type,
+			Kind:          transfer.Kind,
+			Asset:         transfer.Asset.Key,
+			Symbol:        transfer.Asset.Symbol,
+			TokenID:       transfer.TokenID,
+			ChainId:       txCollection.ChainId,
 		}
 
 		// Add to entity map
-		if _, exists := entityMap[counterpartyAddress]; !exists {
-			entityMap[counterpartyAddress] = &models.EntityWithTransactions{
-				Address:      counterpartyAddress,
-				Amount:       0,
-				Transactions: []models.Transaction{},
-			}
-		}
-
-		entityMap[counterpartyAddress].Amount += amount
-		entityMap[counterpartyAddress].Transactions = append(
-			entityMap[counterpartyAddress].Transactions,
-			transaction,
-		)
-	}
-
-	// Process internal transactions (similar logic)
-	for _, tx := range txCollection.InternalTxs {
-		if tx.IsError == 1 {
-			continue
-		}
-
-		var counterpartyAddress string
-		if isOutgoing {
-			if !strings.EqualFold(tx.From, address) {
-				continue
-			}
-			counterpartyAddress = tx.To
-		} else {
-			if !strings.EqualFold(tx.To, address) {
-				continue
-			}
-			counterpartyAddress = tx.From
-		}
-
-		amount := utils.ConvertWeiToEther(tx.Value.String())
-		timestamp := tx.TimeStamp.Time().Unix()
-		dateTime := utils.FormatTimestamp(timestamp)
-
-		transaction := models.Transaction{
-			TxAmount:      amount,
-			DateTime:      dateTime,
-			TransactionID: tx.Hash,
-		}
-
-		if _, exists := entityMap[counterpartyAddress]; !exists {
-			entityMap[counterpartyAddress] = &models.EntityWithTransactions{
-				Address:      counterpartyAddress,
-				Amount:       0,
-				Transactions: []models.Transaction{},
-			}
-		}
-
-		entityMap[counterpartyAddress].Amount += amount
-		entityMap[counterpartyAddress].Transactions = append(
-			entityMap[counterpartyAddress].Transactions,
-			transaction,
-		)
-	}
-
-	// Process ERC20 transfers
-	for _, tx := range txCollection.ERC20Txs {
-		var counterpartyAddress string
-		if isOutgoing {
-			if !strings.EqualFold(tx.From, address) {
-				continue
-			}
-			counterpartyAddress = tx.To
-		} else {
-			if !strings.EqualFold(tx.To, address) {
-				continue
-			}
-			counterpartyAddress = tx.From
-		}
-
-		amount := utils.ConvertTokenValueWithDecimals(tx.Value.String(), tx.TokenDecimal)
-		timestamp := tx.TimeStamp.Time().Unix()
-		dateTime := utils.FormatTimestamp(timestamp)
-
-		transaction := models.Transaction{
-			TxAmount:      amount,
-			DateTime:      dateTime,
-			TransactionID: tx.Hash,
-		}
-
-		if _, ok := entityMap[counterpartyAddress]; !ok {
-			entityMap[counterpartyAddress] = &models.EntityWithTransactions{
+		entity, exists := entityMap[counterpartyAddress]
+		if !exists {
+			entity = &models.EntityWithTransactions{
 				Address:      counterpartyAddress,
-				Amount:       0,
+				Amount:       utils.NewAmount(nil, txCollection.Native.Decimals),
+				Assets:       map[string]*models.AssetAmount{},
 				Transactions: []models.Transaction{},
 			}
+			entityMap[counterpartyAddress] = entity
 		}
 
-		// For token transfers, we don't add to the ETH amount
-		entityMap[counterpartyAddress].Transactions = append(
-			entityMap[counterpartyAddress].Transactions,
-			transaction,
-		)
-	}
-
-	// Process ERC721 transfers (NFTs)
-	for _, tx := range txCollection.ERC721Txs {
-		var counterpartyAddress string
-		if isOutgoing {
-			if !strings.EqualFold(tx.From, address) {
-				continue
-			}
-			counterpartyAddress = tx.To
-		} else {
-			if !strings.EqualFold(tx.To, address) {
-				continue
-			}
-			counterpartyAddress = tx.From
+		if transfer.Kind == models.FlowCreate {
+			entity.Created = true
 		}
 
-		timestamp := tx.TimeStamp.Time().Unix()
-		dateTime := utils.FormatTimestamp(timestamp)
-
-		transaction := models.Transaction{
-			TxAmount:      1, // NFTs always transfer one
-			DateTime:      dateTime,
-			TransactionID: tx.Hash,
+		// Only the native coin counts towards the top-level amount
+		if transfer.Asset.Key == models.NativeAsset {
+			entity.Amount = entity.Amount.Add(amount)
 		}
 
-		if _, ok := entityMap[counterpartyAddress]; !ok {
-			entityMap[counterpartyAddress] = &models.EntityWithTransactions{
-				Address:      counterpartyAddress,
-				Amount:       0,
-				Transactions: []models.Transaction{},
-			}
-		}
-		entityMap[counterpartyAddress].Transactions = append(
-			entityMap[counterpartyAddress].Transactions,
-			transaction,
-		)
-	}
-
-	// Process ERC1155 transfers
-	for _, tx := range txCollection.ERC1155Txs {
-		var counterpartyAddress string
-		if isOutgoing {
-			if !strings.EqualFold(tx.From, address) {
-				continue
-			}
-			counterpartyAddress = tx.To
-		} else {
-			if !strings.EqualFold(tx.To, address) {
-				continue
+		assetAmount, exists := entity.Assets[transfer.Asset.Key]
+		if !exists {
+			assetAmount = &models.AssetAmount{
+				Asset:  transfer.Asset,
+				Amount: utils.NewAmount(nil, transfer.Asset.Decimals),
 			}
-			counterpartyAddress = tx.From
+			entity.Assets[transfer.Asset.Key] = assetAmount
 		}
+		assetAmount.AddTransfer(amount, transfer.Timestamp)
 
-		amount := utils.ConvertTokenValueWithDecimals(tx.TokenValue.String(), tx.TokenDecimal)
-		timestamp := tx.TimeStamp.Time().Unix()
-		dateTime := utils.FormatTimestamp(timestamp)
-
-		transaction := models.Transaction{
-			TxAmount:      amount,
-			DateTime:      dateTime,
-			TransactionID: tx.Hash,
-		}
-
-		if _, ok := entityMap[counterpartyAddress]; !ok {
-			entityMap[counterpartyAddress] = &models.EntityWithTransactions{
-				Address:      counterpartyAddress,
-				Amount:       0,
-				Transactions: []models.Transaction{},
-			}
-		}
-		entityMap[counterpartyAddress].Transactions = append(
-			entityMap[counterpartyAddress].Transactions,
-			transaction,
-		)
+		entity.Transactions = append(entity.Transactions, transaction)
 	}
 
 	return entityMap
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// Risk factors
+const (
+	RiskExposure       = "risky_exposure"      // Volume exchanged with risky counterparties
+	RiskFreshContracts = "fresh_contracts"     // Calls to contracts shortly after their creation
+	RiskFailedTxs      = "failed_transactions" // Share of sent transactions that failed
+	RiskPassThrough    = "pass_through"        // Funds sent on shortly after being received
+	RiskRoundTransfers = "round_transfers"     // Large round-number native transfers
+)
+
+// riskWeights is the share of the score each factor can contribute, adding up to 1
+var riskWeights = map[string]float64{
+	RiskExposure:       0.35,
+	RiskFreshContracts: 0.15,
+	RiskFailedTxs:      0.10,
+	RiskPassThrough:    0.25,
+	RiskRoundTransfers: 0.15,
+}
+
+// maxRiskEvidence is the number of evidence transfers listed per factor
+const maxRiskEvidence = 20
+
+// passThroughTolerance is how far, as a fraction, the amount sent on may differ from the amount received
+const passThroughTolerance = 0.1
+
+// RiskParams contains parameters for risk scoring
+type RiskParams struct {
+	AnalysisParams
+
+	Risky             func(address string) []string // Why a counterparty is risky, e.g. its labels or sanctions listings; empty if it is not
+	FreshContractAge  time.Duration                 // Contracts called within this time of their creation are fresh
+	PassThroughWindow time.Duration                 // Funds sent on within this time of being received pass through
+	RoundMin          *big.Rat                      // Smallest native amount of a round-number transfer
+	MaxContracts      int                           // Most called contracts whose creation is looked up
+}
+
+// AnalyzeRisk scores the risk of params.Address from its transactions, explaining the score
+// by the contribution of each factor and the transfers supporting it
+func (s *AnalysisService) AnalyzeRisk(ctx context.Context, params RiskParams) (models.RiskReport, models.FetchStatus, error) {
+	// Fetch all transactions concurrently
+	txCollection, err := s.fetchTransactions(ctx, params.AnalysisParams)
+	if err != nil {
+		return models.RiskReport{}, models.FetchStatus{}, err
+	}
+
+	address := strings.ToLower(params.Address)
+	transfers := CollectTransfers(txCollection)
+
+	report := models.RiskReport{
+		Address: address,
+		ChainId: txCollection.ChainId,
+		Factors: []models.RiskFactor{
+			riskyExposure(address, transfers, params.Risky),
+			s.freshContracts(ctx, address, txCollection, params),
+			failedTransactions(address, txCollection.NormalTxs),
+			passThrough(address, transfers, params.PassThroughWindow),
+			roundTransfers(address, transfers, txCollection.Native, params.RoundMin),
+		},
+	}
+	for _, factor := range report.Factors {
+		report.Score += factor.Score
+	}
+	report.Score = math.Round(report.Score*10) / 10
+	report.Level = riskLevel(report.Score)
+
+	return report, txCollection.Status(), nil
+}
+
+// riskLevel names the band a score falls in
+func riskLevel(score float64) string {
+	switch {
+	case score < 25:
+		return "low"
+	case score < 50:
+		return "medium"
+	case score < 75:
+		return "high"
+	default:
+		return "critical"
+	}
+}
+
+// scoreFactor creates a factor of the given strength, listing its earliest evidence
+func scoreFactor(name string, signal float64, description string, evidence []models.RiskEvidence) models.RiskFactor {
+	sort.SliceStable(evidence, func(i, j int) bool { return evidence[i].Timestamp < evidence[j].Timestamp })
+
+	factor := models.RiskFactor{
+		Name:          name,
+		Description:   description,
+		Weight:        riskWeights[name],
+		Signal:        math.Round(signal*1000) / 1000,
+		Score:         math.Round(riskWeights[name]*signal*1000) / 10,
+		Evidence:      []models.RiskEvidence{},
+		EvidenceCount: len(evidence),
+	}
+	if len(evidence) > 0 {
+		factor.Evidence = evidence[:min(len(evidence), maxRiskEvidence)]
+	}
+	return factor
+}
+
+// unavailableFactor creates a factor that could not be evaluated and contributes nothing
+func unavailableFactor(name string, err error) models.RiskFactor {
+	factor := scoreFactor(name, 0, "Not evaluated", nil)
+	factor.Unavailable = err.Error()
+	return factor
+}
+
+// assetShare is the part of the volume of one asset that a factor applies to
+type assetShare struct {
+	asset models.Asset
+	total *big.Int
+	part  *big.Int
+}
+
+// shareOf returns the share of asset in shares, creating it if needed
+func shareOf(shares map[string]*assetShare, asset models.Asset) *assetShare {
+	share, ok := shares[asset.Key]
+	if !ok {
+		share = &assetShare{asset: asset, total: new(big.Int), part: new(big.Int)}
+		shares[asset.Key] = share
+	}
+	return share
+}
+
+// largestShare returns the asset with the largest part of its volume affected, and that part
+func largestShare(shares map[string]*assetShare) (models.Asset, float64) {
+	keys := make([]string, 0, len(shares))
+	for key := range shares {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var asset models.Asset
+	largest := 0.0
+	for _, key := range keys {
+		share := shares[key]
+		if share.total.Sign() == 0 {
+			continue
+		}
+		ratio, _ := new(big.Rat).SetFrac(share.part, share.total).Float64()
+		if ratio > largest {
+			asset, largest = share.asset, ratio
+		}
+	}
+	return asset, largest
+}
+
+// transferSide returns the counterparty of a transfer involving address and whether it was sent
+func transferSide(transfer Transfer, address string) (string, bool, bool) {
+	switch {
+	case strings.EqualFold(transfer.From, address):
+		return strings.ToLower(transfer.To), true, true
+	case strings.EqualFold(transfer.To, address):
+		return strings.ToLower(transfer.From), false, true
+	default:
+		return "", false, false
+	}
+}
+
+// describeTransfer describes a transfer from the point of view of the analyzed address
+func describeTransfer(transfer Transfer, counterparty string, outgoing bool) string {
+	if outgoing {
+		return fmt.Sprintf("sent %s %s to %s", transfer.Amount(), transfer.Asset.Symbol, counterparty)
+	}
+	return fmt.Sprintf("received %s %s from %s", transfer.Amount(), transfer.Asset.Symbol, counterparty)
+}
+
+// riskyExposure measures the largest share of the volume of an asset exchanged with risky counterparties
+func riskyExposure(address string, transfers []Transfer, risky func(address string) []string) models.RiskFactor {
+	shares := map[string]*assetShare{}
+	reasons := map[string][]string{}
+	var evidence []models.RiskEvidence
+
+	for _, transfer := range transfers {
+		counterparty, outgoing, ok := transferSide(transfer, address)
+		if !ok || transfer.Failed {
+			continue
+		}
+		share := shareOf(shares, transfer.Asset)
+		share.total.Add(share.total, transfer.Value)
+
+		if risky == nil {
+			continue
+		}
+		why, seen := reasons[counterparty]
+		if !seen {
+			why = risky(counterparty)
+			reasons[counterparty] = why
+		}
+		if len(why) == 0 {
+			continue
+		}
+
+		share.part.Add(share.part, transfer.Value)
+		evidence = append(evidence, models.RiskEvidence{
+			TxHashes:  []string{transfer.Hash},
+			Timestamp: transfer.Timestamp,
+			Detail:    fmt.Sprintf("%s (%s)", describeTransfer(transfer, counterparty, outgoing), strings.Join(why, "; ")),
+		})
+	}
+
+	asset, signal := largestShare(shares)
+	description := "No transfers with risky counterparties"
+	if signal > 0 {
+		description = fmt.Sprintf("%.1f%% of the %s volume was exchanged with risky counterparties", signal*100, asset.Symbol)
+	}
+	return scoreFactor(RiskExposure, signal, description, evidence)
+}
+
+// freshContracts measures the share of the contracts called by address that
+// were first called within params.FreshContractAge of their creation
+func (s *AnalysisService) freshContracts(ctx context.Context, address string, txCollection TransactionCollection, params RiskParams) models.RiskFactor {
+	// Find the first call to every contract
+	first := map[string]models.NormalTx{}
+	calls := map[string]int{}
+	for _, tx := range txCollection.NormalTxs {
+		if !strings.EqualFold(tx.From, address) || tx.To == "" || tx.Input == "" || tx.Input == "0x" {
+			continue
+		}
+		contract := strings.ToLower(tx.To)
+		calls[contract]++
+		if f, ok := first[contract]; !ok || tx.TimeStamp.Time().Before(f.TimeStamp.Time()) {
+			first[contract] = tx
+		}
+	}
+	if len(first) == 0 {
+		return scoreFactor(RiskFreshContracts, 0, "No contract calls", nil)
+	}
+
+	// Look up the most called contracts
+	contracts := make([]string, 0, len(first))
+	for contract := range first {
+		contracts = append(contracts, contract)
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		if calls[contracts[i]] != calls[contracts[j]] {
+			return calls[contracts[i]] > calls[contracts[j]]
+		}
+		return contracts[i] < contracts[j]
+	})
+	if params.MaxContracts > 0 && len(contracts) > params.MaxContracts {
+		contracts = contracts[:params.MaxContracts]
+	}
+
+	creations, err := s.contractCreations(ctx, params.Source, params.ApiKey, txCollection.ChainId, contracts)
+	if err != nil {
+		return unavailableFactor(RiskFreshContracts, err)
+	}
+
+	var evidence []models.RiskEvidence
+	for _, contract := range contracts {
+		creation, ok := creations[contract]
+		if !ok {
+			continue
+		}
+		tx := first[contract]
+		age := tx.TimeStamp.Time().Sub(creation.TimeStamp.Time())
+		if age < 0 || age > params.FreshContractAge {
+			continue
+		}
+		evidence = append(evidence, models.RiskEvidence{
+			TxHashes:  []string{tx.Hash, creation.TxHash},
+			Timestamp: tx.TimeStamp.Time().Unix(),
+			Detail:    fmt.Sprintf("first called %s %s after it was created by %s", contract, age, strings.ToLower(creation.ContractCreator)),
+		})
+	}
+
+	signal := float64(len(evidence)) / float64(len(contracts))
+	description := fmt.Sprintf("%d of the %d contracts called were first called within %s of their creation",
+		len(evidence), len(contracts), params.FreshContractAge)
+	return scoreFactor(RiskFreshContracts, signal, description, evidence)
+}
+
+// failedTransactions measures the share of the transactions sent by address that failed
+func failedTransactions(address string, normalTxs []models.NormalTx) models.RiskFactor {
+	sent := 0
+	var evidence []models.RiskEvidence
+	for _, tx := range normalTxs {
+		if !strings.EqualFold(tx.From, address) {
+			continue
+		}
+		sent++
+		if tx.IsError == 0 {
+			continue
+		}
+
+		detail := "failed transaction to " + strings.ToLower(tx.To)
+		if tx.FunctionName != "" {
+			detail += " calling " + tx.FunctionName
+		}
+		evidence = append(evidence, models.RiskEvidence{
+			TxHashes:  []string{tx.Hash},
+			Timestamp: tx.TimeStamp.Time().Unix(),
+			Detail:    detail,
+		})
+	}
+	if sent == 0 {
+		return scoreFactor(RiskFailedTxs, 0, "No transactions sent", nil)
+	}
+
+	signal := float64(len(evidence)) / float64(sent)
+	description := fmt.Sprintf("%d of the %d transactions sent failed", len(evidence), sent)
+	return scoreFactor(RiskFailedTxs, signal, description, evidence)
+}
+
+// passThrough measures the largest share of the amount of an asset received that was sent on
+// within window, in a transfer of about the same amount. NFTs are not considered.
+func passThrough(address string, transfers []Transfer, window time.Duration) models.RiskFactor {
+	type side struct {
+		transfer     Transfer
+		counterparty string
+	}
+	ins, outs := map[string][]side{}, map[string][]side{}
+	shares := map[string]*assetShare{}
+
+	for _, transfer := range transfers {
+		counterparty, outgoing, ok := transferSide(transfer, address)
+		if !ok || transfer.Failed || transfer.Value.Sign() == 0 ||
+			transfer.Asset.Standard == models.TransferERC721 || transfer.Asset.Standard == models.TransferERC1155 {
+			continue
+		}
+		if outgoing {
+			outs[transfer.Asset.Key] = append(outs[transfer.Asset.Key], side{transfer, counterparty})
+		} else {
+			ins[transfer.Asset.Key] = append(ins[transfer.Asset.Key], side{transfer, counterparty})
+		}
+	}
+
+	var evidence []models.RiskEvidence
+	for key, received := range ins {
+		sent := outs[key]
+		sort.Slice(received, func(i, j int) bool { return received[i].transfer.Timestamp < received[j].transfer.Timestamp })
+		sort.Slice(sent, func(i, j int) bool { return sent[i].transfer.Timestamp < sent[j].transfer.Timestamp })
+
+		// Match every receipt with the first unmatched transfer of about the same amount sent after it
+		matched := make([]bool, len(sent))
+		first := 0
+		for _, in := range received {
+			share := shareOf(shares, in.transfer.Asset)
+			share.total.Add(share.total, in.transfer.Value)
+
+			for first < len(sent) && sent[first].transfer.Timestamp < in.transfer.Timestamp {
+				first++
+			}
+			for j := first; j < len(sent); j++ {
+				out := sent[j]
+				elapsed := time.Duration(out.transfer.Timestamp-in.transfer.Timestamp) * time.Second
+				if elapsed > window {
+					break
+				}
+				if matched[j] || !similarAmount(in.transfer.Value, out.transfer.Value) {
+					continue
+				}
+
+				matched[j] = true
+				share.part.Add(share.part, in.transfer.Value)
+				evidence = append(evidence, models.RiskEvidence{
+					TxHashes:  []string{in.transfer.Hash, out.transfer.Hash},
+					Timestamp: in.transfer.Timestamp,
+					Detail: fmt.Sprintf("%s and %s %s later", describeTransfer(in.transfer, in.counterparty, false),
+						describeTransfer(out.transfer, out.counterparty, true), elapsed),
+				})
+				break
+			}
+		}
+	}
+
+	asset, signal := largestShare(shares)
+	description := fmt.Sprintf("No funds were sent on within %s of being received", window)
+	if signal > 0 {
+		description = fmt.Sprintf("%.1f%% of the %s received was sent on within %s", signal*100, asset.Symbol, window)
+	}
+	return scoreFactor(RiskPassThrough, signal, description, evidence)
+}
+
+// similarAmount reports whether sent is within passThroughTolerance of received
+func similarAmount(received, sent *big.Int) bool {
+	ratio, _ := new(big.Rat).SetFrac(sent, received).Float64()
+	return math.Abs(ratio-1) <= passThroughTolerance
+}
+
+// roundTransfers measures the share of the native volume moved in transfers of at least
+// minAmount that are whole multiples of ten coins
+func roundTransfers(address string, transfers []Transfer, native models.Asset, minAmount *big.Rat) models.RiskFactor {
+	tenCoins := new(big.Int).Mul(big.NewInt(10), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(native.Decimals)), nil))
+	share := &assetShare{asset: native, total: new(big.Int), part: new(big.Int)}
+	var evidence []models.RiskEvidence
+
+	for _, transfer := range transfers {
+		counterparty, outgoing, ok := transferSide(transfer, address)
+		if !ok || transfer.Failed || transfer.Asset.Key != models.NativeAsset {
+			continue
+		}
+		share.total.Add(share.total, transfer.Value)
+
+		if transfer.Value.Sign() == 0 || new(big.Int).Rem(transfer.Value, tenCoins).Sign() != 0 {
+			continue
+		}
+		if minAmount != nil && transfer.Amount().Cmp(minAmount) < 0 {
+			continue
+		}
+
+		share.part.Add(share.part, transfer.Value)
+		evidence = append(evidence, models.RiskEvidence{
+			TxHashes:  []string{transfer.Hash},
+			Timestamp: transfer.Timestamp,
+			Detail:    describeTransfer(transfer, counterparty, outgoing),
+		})
+	}
+
+	_, signal := largestShare(map[string]*assetShare{native.Key: share})
+	description := fmt.Sprintf("No round-number transfers of %s", native.Symbol)
+	if signal > 0 {
+		description = fmt.Sprintf("%d transfers of whole multiples of 10 %s make up %.1f%% of the %s volume",
+			len(evidence), native.Symbol, signal*100, native.Symbol)
+	}
+	return scoreFactor(RiskRoundTransfers, signal, description, evidence)
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/utils"
+)
+
+// Time series intervals
+const (
+	IntervalHour  = "hour"
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// TimeSeriesParams contains parameters for a time series analysis
+type TimeSeriesParams struct {
+	AnalysisParams
+
+	Interval string // Bucket width: IntervalHour, IntervalDay, IntervalWeek or IntervalMonth
+	Asset    string // Only bin this asset; empty bins every asset
+}
+
+// bucketFlow accumulates the flow of one asset in one bucket
+type bucketFlow struct {
+	flow *models.BucketFlow
+	in   map[string]struct{}
+	out  map[string]struct{}
+}
+
+// AnalyzeTimeSeries bins the inflows and outflows of params.Address by time and asset
+func (s *AnalysisService) AnalyzeTimeSeries(ctx context.Context, params TimeSeriesParams) (models.TimeSeries, models.FetchStatus, error) {
+	series := models.TimeSeries{
+		Address:  strings.ToLower(params.Address),
+		Interval: params.Interval,
+		Buckets:  []models.TimeBucket{},
+
+		Counterparties: map[string][]string{},
+	}
+
+	// Fetch all transactions concurrently
+	txCollection, err := s.fetchTransactions(ctx, params.AnalysisParams)
+	if err != nil {
+		return series, models.FetchStatus{}, err
+	}
+
+	buckets := map[int64]map[string]*bucketFlow{}
+	for _, transfer := range CollectTransfers(txCollection) {
+		// Skip failed transactions
+		if transfer.Failed {
+			continue
+		}
+		if params.Asset != "" && transfer.Asset.Key != params.Asset {
+			continue
+		}
+
+		// Self-transfers count in both directions
+		outgoing := strings.EqualFold(transfer.From, params.Address)
+		incoming := strings.EqualFold(transfer.To, params.Address)
+		if !outgoing && !incoming {
+			continue
+		}
+
+		start, err := bucketStart(transfer.Timestamp, params.Interval)
+		if err != nil {
+			return series, models.FetchStatus{}, err
+		}
+
+		assets, exists := buckets[start]
+		if !exists {
+			assets = map[string]*bucketFlow{}
+			buckets[start] = assets
+		}
+
+		b, exists := assets[transfer.Asset.Key]
+		if !exists {
+			b = &bucketFlow{
+				flow: &models.BucketFlow{
+					Asset:   transfer.Asset,
+					Inflow:  utils.NewAmount(nil, transfer.Asset.Decimals),
+					Outflow: utils.NewAmount(nil, transfer.Asset.Decimals),
+				},
+				in:  map[string]struct{}{},
+				out: map[string]struct{}{},
+			}
+			assets[transfer.Asset.Key] = b
+		}
+
+		amount := transfer.Amount()
+		if incoming {
+			b.flow.Inflow = b.flow.Inflow.Add(amount)
+			b.flow.InCount++
+			b.in[strings.ToLower(transfer.From)] = struct{}{}
+			addCounterpartyHash(series.Counterparties, transfer.From, transfer.Hash)
+		}
+		if outgoing {
+			b.flow.Outflow = b.flow.Outflow.Add(amount)
+			b.flow.OutCount++
+			b.out[strings.ToLower(transfer.To)] = struct{}{}
+			addCounterpartyHash(series.Counterparties, transfer.To, transfer.Hash)
+		}
+	}
+
+	// Convert the buckets to chronological order, counting distinct counterparties
+	for start, assets := range buckets {
+		bucket := models.TimeBucket{
+			Start:    start,
+			DateTime: time.Unix(start, 0).UTC().Format(time.RFC3339),
+			Assets:   make(map[string]*models.BucketFlow, len(assets)),
+		}
+		for key, b := range assets {
+			b.flow.InCounterparties = len(b.in)
+			b.flow.OutCounterparties = len(b.out)
+			b.flow.Counterparties = len(b.in)
+			for address := range b.out {
+				if _, ok := b.in[address]; !ok {
+					b.flow.Counterparties++
+				}
+			}
+			bucket.Assets[key] = b.flow
+		}
+		series.Buckets = append(series.Buckets, bucket)
+	}
+	sort.Slice(series.Buckets, func(i, j int) bool {
+		return series.Buckets[i].Start < series.Buckets[j].Start
+	})
+
+	return series, txCollection.Status(), nil
+}
+
+// addCounterpartyHash records a transaction with a counterparty, skipping transfers without one
+func addCounterpartyHash(counterparties map[string][]string, address, hash string) {
+	if address == "" {
+		return
+	}
+	address = strings.ToLower(address)
+	counterparties[address] = append(counterparties[address], hash)
+}
+
+// bucketStart returns the Unix time of the start of the interval holding timestamp, in UTC.
+// Weeks start on Monday.
+func bucketStart(timestamp int64, interval string) (int64, error) {
+	t := time.Unix(timestamp, 0).UTC()
+
+	switch interval {
+	case IntervalHour:
+		t = t.Truncate(time.Hour)
+	case IntervalDay:
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case IntervalWeek:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		t = time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+	case IntervalMonth:
+		t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return 0, fmt.Errorf("unsupported interval %q", interval)
+	}
+
+	return t.Unix(), nil
+}
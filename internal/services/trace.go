@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/client"
+	"Ethereum-fund-flow-analysis/internal/models"
+)
+
+// TraceParams contains parameters for a multi-hop trace
+type TraceParams struct {
+	AnalysisParams
+
+	Outgoing  bool     // Follow outflows (beneficiaries) when true, inflows (payers) otherwise
+	MaxHops   int      // Number of hops to follow from the root address
+	MaxFanOut int      // Maximum number of counterparties expanded per address
+	MaxNodes  int      // Maximum number of addresses expanded over the whole trace; 0 expands every address reached
+	MinAmount *big.Rat // Minimum aggregated amount for an edge to be followed; nil follows every edge
+	Asset     string   // Asset the minimum and fan-out ranking apply to; empty ranks by native amount
+}
+
+// TraceFlow follows funds from params.Address for up to params.MaxHops hops,
+// expanding the largest counterparties of each address it reaches until
+// params.MaxNodes addresses were expanded. Addresses beyond the root whose
+// transactions cannot be fetched are left unexpanded with a warning.
+func (s *AnalysisService) TraceFlow(ctx context.Context, params TraceParams) (models.FlowGraph, models.FetchStatus, error) {
+	root := strings.ToLower(params.Address)
+	direction := "in"
+	if params.Outgoing {
+		direction = "out"
+	}
+
+	graph := models.FlowGraph{
+		Root:      root,
+		Direction: direction,
+		Nodes:     []models.FlowNode{{Address: root, Depth: 0}},
+		Edges:     []models.FlowEdge{},
+	}
+
+	// visited maps an address to its index in graph.Nodes
+	visited := map[string]int{root: 0}
+	frontier := []string{root}
+	status := models.FetchStatus{Complete: true}
+	expanded, skipped := 0, 0
+
+	// Share cache statistics across every fetch of the trace
+	ctx, cacheStats := client.WithCacheStats(ctx)
+	defer func() { status.Cache = cacheStats.Status() }()
+
+	for hop := 1; hop <= params.MaxHops && len(frontier) > 0; hop++ {
+		var next []string
+
+		for _, address := range frontier {
+			if params.MaxNodes > 0 && expanded >= params.MaxNodes {
+				skipped++
+				continue
+			}
+			expanded++
+
+			hopParams := params.AnalysisParams
+			hopParams.Address = address
+
+			txCollection, err := s.fetchTransactions(ctx, hopParams)
+			// Addresses reached along the way may have no other activity
+			if hop > 1 && errors.Is(err, client.ErrNoTransactions) {
+				err = nil
+			}
+			// Past the root, a failed address leaves a gap in the graph rather than failing it
+			if err != nil && hop > 1 && ctx.Err() == nil {
+				status.Complete = false
+				status.Warnings = append(status.Warnings, models.Warning{TransactionType: "transactions", Address: address, Error: err.Error()})
+				continue
+			}
+			if err != nil {
+				return graph, status, fmt.Errorf("%s: %w", address, err)
+			}
+			graph.Nodes[visited[address]].Expanded = true
+
+			for _, name := range txCollection.Truncated {
+				status.Complete = false
+				status.Truncated = append(status.Truncated, address+" "+name)
+			}
+			for _, warning := range txCollection.Warnings {
+				warning.Address = address
+				status.Complete = false
+				status.Warnings = append(status.Warnings, warning)
+			}
+
+			entityMap := ProcessTransactions(address, txCollection, params.Outgoing)
+			for _, entity := range topCounterparties(entityMap, params.Asset, params.MinAmount, params.MaxFanOut) {
+				counterparty := strings.ToLower(entity.Address)
+
+				edge := models.FlowEdge{
+					From:     address,
+					To:       counterparty,
+					Amount:   entity.Amount,
+					Assets:   entity.Assets,
+					TxHashes: make([]string, 0, len(entity.Transactions)),
+					Created:  entity.Created,
+				}
+				if !params.Outgoing {
+					edge.From, edge.To = counterparty, address
+				}
+				for _, tx := range entity.Transactions {
+					edge.TxHashes = append(edge.TxHashes, tx.TransactionID)
+				}
+				graph.Edges = append(graph.Edges, edge)
+
+				if _, seen := visited[counterparty]; seen {
+					continue
+				}
+				visited[counterparty] = len(graph.Nodes)
+				graph.Nodes = append(graph.Nodes, models.FlowNode{Address: counterparty, Depth: hop})
+				next = append(next, counterparty)
+			}
+		}
+
+		frontier = next
+	}
+
+	if skipped > 0 {
+		status.Complete = false
+		status.Warnings = append(status.Warnings, models.Warning{
+			TransactionType: "trace",
+			Error:           fmt.Sprintf("%d reached addresses left unexpanded, beyond max_nodes of %d", skipped, params.MaxNodes),
+		})
+	}
+
+	return graph, status, nil
+}
+
+// topCounterparties returns up to maxFanOut counterparties that moved at least
+// minAmount, ranked by their amount of asset (or native amount), largest first.
+// Without an asset, a counterparty qualifies if any of its assets reaches minAmount.
+func topCounterparties(entityMap map[string]*models.EntityWithTransactions, asset string, minAmount *big.Rat, maxFanOut int) []*models.EntityWithTransactions {
+	entities := make([]*models.EntityWithTransactions, 0, len(entityMap))
+	for _, entity := range entityMap {
+		// Transfers without a counterparty address cannot be followed
+		if entity.Address == "" || !reachesAmount(entity, asset, minAmount) {
+			continue
+		}
+		entities = append(entities, entity)
+	}
+
+	sort.Slice(entities, func(i, j int) bool {
+		if c := entities[i].AmountOf(asset).CmpAmount(entities[j].AmountOf(asset)); c != 0 {
+			return c > 0
+		}
+		return entities[i].Address < entities[j].Address
+	})
+
+	if maxFanOut > 0 && len(entities) > maxFanOut {
+		entities = entities[:maxFanOut]
+	}
+
+	return entities
+}
+
+// reachesAmount reports whether the entity moved at least minAmount of asset,
+// or of any asset if asset is empty
+func reachesAmount(entity *models.EntityWithTransactions, asset string, minAmount *big.Rat) bool {
+	if minAmount == nil {
+		return true
+	}
+	if asset != "" {
+		return entity.AmountOf(asset).Cmp(minAmount) >= 0
+	}
+	if entity.Amount.Cmp(minAmount) >= 0 {
+		return true
+	}
+	for _, a := range entity.Assets {
+		if a.Amount.Cmp(minAmount) >= 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"math/big"
+	"strings"
+
+	"Ethereum-fund-flow-analysis/internal/models"
+	"Ethereum-fund-flow-analysis/internal/utils"
+)
+
+// nativeAsset describes the native coin of chain
+func nativeAsset(chain models.Chain) models.Asset {
+	return models.Asset{
+		Key:      models.NativeAsset,
+		Standard: "native",
+		Symbol:   chain.Native.Symbol,
+		Name:     chain.Native.Name,
+		Decimals: chain.Native.Decimals,
+	}
+}
+
+// Transfer is a single movement of value taken from any of the transaction lists
+type Transfer struct {
+	Type      string
+	Kind      string // models.FlowTransfer, or models.FlowCreate for value sent to a contract being created
+	Hash      string
+	From      string
+	To        string
+	Asset     models.Asset
+	Value     *big.Int // Raw value in the asset's base units
+	TokenID   string
+	Block     int64
+	Timestamp int64
+	GasUsed   int           // Gas used by the enclosing transaction or call
+	GasPrice  *utils.BigInt // Gas price of the enclosing transaction, nil for internal transactions
+	Failed    bool
+}
+
+// Counterparty returns the other side of the transfer relative to address
+// and whether the transfer goes in the requested direction
+func (t Transfer) Counterparty(address string, isOutgoing bool) (string, bool) {
+	if isOutgoing {
+		return t.To, strings.EqualFold(t.From, address)
+	}
+	return t.From, strings.EqualFold(t.To, address)
+}
+
+// Amount returns the exact transfer value in the asset's decimals
+func (t Transfer) Amount() utils.Amount {
+	return utils.NewAmount(t.Value, t.Asset.Decimals)
+}
+
+// tokenAsset builds the asset description of a token contract
+func tokenAsset(standard, contract, symbol, name string, decimals uint8) models.Asset {
+	return models.Asset{
+		Key:      strings.ToLower(contract),
+		Standard: standard,
+		Symbol:   symbol,
+		Name:     name,
+		Decimals: decimals,
+	}
+}
+
+// bigValue returns v as a *big.Int, treating a missing value as zero
+func bigValue(v *utils.BigInt) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return v.Int()
+}
+
+// CollectTransfers flattens every transaction type of the collection into transfers
+func CollectTransfers(txCollection TransactionCollection) []Transfer {
+	transfers := make([]Transfer, 0,
+		len(txCollection.NormalTxs)+len(txCollection.InternalTxs)+len(txCollection.ERC20Txs)+
+			len(txCollection.ERC721Txs)+len(txCollection.ERC1155Txs))
+
+	for _, tx := range txCollection.NormalTxs {
+		transfers = append(transfers, normalTransfer(tx, txCollection.Native))
+	}
+	for _, tx := range txCollection.InternalTxs {
+		transfers = append(transfers, internalTransfer(tx, txCollection.Native))
+	}
+	for _, tx := range txCollection.ERC20Txs {
+		transfers = append(transfers, erc20Transfer(tx))
+	}
+	// The NFTs of a contract that is also an ERC-20 token are a separate asset
+	fungible := map[string]bool{}
+	for _, tx := range txCollection.ERC20Txs {
+		fungible[strings.ToLower(tx.ContractAddress)] = true
+	}
+	nft := func(transfer Transfer) Transfer {
+		if fungible[transfer.Asset.Key] {
+			transfer.Asset.Key = models.NFTAssetKey(transfer.Asset.Key, transfer.Asset.Standard)
+		}
+		return transfer
+	}
+	for _, tx := range txCollection.ERC721Txs {
+		transfers = append(transfers, nft(erc721Transfer(tx)))
+	}
+	for _, tx := range txCollection.ERC1155Txs {
+		transfers = append(transfers, nft(erc1155Transfer(tx)))
+	}
+
+	return transfers
+}
+
+// normalTransfer converts a normal transaction into a transfer of the native coin.
+// A contract deployment, which has no recipient, transfers to the created contract.
+func normalTransfer(tx models.NormalTx, native models.Asset) Transfer {
+	transfer := Transfer{
+		Type:      models.TransferNormal,
+		Kind:      models.FlowTransfer,
+		Hash:      tx.Hash,
+		From:      tx.From,
+		To:        tx.To,
+		Asset:     native,
+		Value:     bigValue(tx.Value),
+		Block:     int64(tx.BlockNumber),
+		Timestamp: tx.TimeStamp.Time().Unix(),
+		GasUsed:   tx.GasUsed,
+		GasPrice:  tx.GasPrice,
+		Failed:    tx.IsError == 1,
+	}
+	if tx.To == "" && tx.ContractAddress != "" {
+		transfer.Kind, transfer.To = models.FlowCreate, tx.ContractAddress
+	}
+	return transfer
+}
+
+// internalTransfer converts an internal transaction into a transfer of the native coin.
+// A create or create2 call transfers to the created contract.
+func internalTransfer(tx models.InternalTx, native models.Asset) Transfer {
+	transfer := Transfer{
+		Type:      models.TransferInternal,
+		Kind:      models.FlowTransfer,
+		Hash:      tx.Hash,
+		From:      tx.From,
+		To:        tx.To,
+		Asset:     native,
+		Value:     bigValue(tx.Value),
+		Block:     int64(tx.BlockNumber),
+		Timestamp: tx.TimeStamp.Time().Unix(),
+		GasUsed:   tx.GasUsed,
+		Failed:    tx.IsError == 1,
+	}
+	if strings.HasPrefix(strings.ToLower(tx.Type), "create") {
+		transfer.Kind = models.FlowCreate
+		if transfer.To == "" {
+			transfer.To = tx.ContractAddress
+		}
+	}
+	return transfer
+}
+
+// erc20Transfer converts an ERC-20 transfer event into a transfer of the token
+func erc20Transfer(tx models.ERC20Transfer) Transfer {
+	return Transfer{
+		Type:      models.TransferERC20,
+		Kind:      models.FlowTransfer,
+		Hash:      tx.Hash,
+		From:      tx.From,
+		To:        tx.To,
+		Asset:     tokenAsset(models.TransferERC20, tx.ContractAddress, tx.TokenSymbol, tx.TokenName, tx.TokenDecimal),
+		Value:     bigValue(tx.Value),
+		Block:     int64(tx.BlockNumber),
+		Timestamp: tx.TimeStamp.Time().Unix(),
+		GasUsed:   tx.GasUsed,
+		GasPrice:  tx.GasPrice,
+	}
+}
+
+// erc721Transfer converts an ERC-721 transfer event into a transfer of one token
+func erc721Transfer(tx models.ERC721Transfer) Transfer {
+	return Transfer{
+		Type:      models.TransferERC721,
+		Kind:      models.FlowTransfer,
+		Hash:      tx.Hash,
+		From:      tx.From,
+		To:        tx.To,
+		Asset:     tokenAsset(models.TransferERC721, tx.ContractAddress, tx.TokenSymbol, tx.TokenName, 0),
+		Value:     big.NewInt(1), // NFTs always transfer one
+		TokenID:   bigValue(tx.TokenID).String(),
+		Block:     int64(tx.BlockNumber),
+		Timestamp: tx.TimeStamp.Time().Unix(),
+		GasUsed:   tx.GasUsed,
+		GasPrice:  tx.GasPrice,
+	}
+}
+
+// erc1155Transfer converts an ERC-1155 transfer event into a transfer of the token
+func erc1155Transfer(tx models.ERC1155Transfer) Transfer {
+	return Transfer{
+		Type:      models.TransferERC1155,
+		Kind:      models.FlowTransfer,
+		Hash:      tx.Hash,
+		From:      tx.From,
+		To:        tx.To,
+		Asset:     tokenAsset(models.TransferERC1155, tx.ContractAddress, tx.TokenSymbol, tx.TokenName, tx.TokenDecimal),
+		Value:     bigValue(tx.TokenValue),
+		TokenID:   bigValue(tx.TokenID).String(),
+		Block:     int64(tx.BlockNumber),
+		Timestamp: tx.TimeStamp.Time().Unix(),
+		GasUsed:   tx.GasUsed,
+		GasPrice:  tx.GasPrice,
+	}
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lockStripes is the number of locks transaction lists are spread over
+const lockStripes = 256
+
+// cachedRecords is the number of recently used records kept decoded in memory
+const cachedRecords = 32
+
+// Store persists the transaction lists of addresses as JSON files laid out as
+// <dir>/<source>/<chainid>/<address>/<list>.json. The most recently used records
+// are kept in memory, so repeat requests need not read and decode their files.
+type Store struct {
+	dir   string
+	locks [lockStripes]sync.Mutex // Lists share the lock of their stripe
+
+	mu      sync.Mutex
+	records map[string]any // Record[T] by path
+	order   []string       // Paths in records, least recently used first
+}
+
+// Record is a stored transaction list together with the block it is synced up to
+type Record[T any] struct {
+	SyncedBlock     int64     `json:"synced_block"`               // Every item up to this block is stored, -1 if never synced
+	TruncatedBlocks []int64   `json:"truncated_blocks,omitempty"` // Blocks that held more items than could be retrieved
+	Updated         time.Time `json:"updated"`
+	Items           []T       `json:"items"` // Items in ascending block order
+}
+
+// New creates a store in dir, creating the directory if needed
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating store directory: %w", err)
+	}
+
+	return &Store{
+		dir:     dir,
+		records: map[string]any{},
+	}, nil
+}
+
+// path returns the file holding a transaction list
+func (s *Store) path(source string, chainId int, address, list string) string {
+	return filepath.Join(s.dir, source, strconv.Itoa(chainId), strings.ToLower(address), list+".json")
+}
+
+// Lock serializes access to a transaction list and returns the function releasing it
+func (s *Store) Lock(source string, chainId int, address, list string) func() {
+	hash := fnv.New32a()
+	hash.Write([]byte(s.path(source, chainId, address, list)))
+
+	lock := &s.locks[hash.Sum32()%lockStripes]
+	lock.Lock()
+	return lock.Unlock
+}
+
+// cached returns the record of path kept in memory, marking it as recently used
+func (s *Store) cached(path string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[path]
+	if ok {
+		s.order = append(slices.DeleteFunc(s.order, func(p string) bool { return p == path }), path)
+	}
+	return record, ok
+}
+
+// cache keeps the record of path in memory, evicting the least recently used record if needed
+func (s *Store) cache(path string, record any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[path]; ok {
+		s.order = slices.DeleteFunc(s.order, func(p string) bool { return p == path })
+	} else if len(s.order) >= cachedRecords {
+		delete(s.records, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.records[path] = record
+	s.order = append(s.order, path)
+}
+
+// Load reads a transaction list, returning an empty record if it was never stored
+func Load[T any](s *Store, source string, chainId int, address, list string) (Record[T], error) {
+	path := s.path(source, chainId, address, list)
+	if cached, ok := s.cached(path); ok {
+		if record, ok := cached.(Record[T]); ok {
+			return record, nil
+		}
+	}
+
+	record := Record[T]{SyncedBlock: -1}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return record, nil
+	}
+	if err != nil {
+		return record, fmt.Errorf("error reading stored transactions: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, fmt.Errorf("error unmarshaling stored transactions: %w", err)
+	}
+
+	Remember(s, source, chainId, address, list, record)
+	return record, nil
+}
+
+// Remember keeps a record in memory without writing it, e.g. when only its synced block
+// advanced. The file keeps the previous record, which is synced again after a restart.
+func Remember[T any](s *Store, source string, chainId int, address, list string, record Record[T]) {
+	// Clipping makes appends to the items of a loaded record copy them
+	record.Items = slices.Clip(record.Items)
+	s.cache(s.path(source, chainId, address, list), record)
+}
+
+// Save writes a transaction list, replacing the previous file atomically
+func Save[T any](s *Store, source string, chainId int, address, list string, record Record[T]) error {
+	path := s.path(source, chainId, address, list)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating store directory: %w", err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error marshaling stored transactions: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), list+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error writing stored transactions: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error writing stored transactions: %w", err)
+	}
+
+	Remember(s, source, chainId, address, list, record)
+	return nil
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Amount is an exact asset amount, kept as an integer number of base units
+// together with the decimals of the asset
+type Amount struct {
+	raw      *big.Int
+	decimals uint8
+}
+
+// NewAmount creates an amount of raw base units with the given decimals
+func NewAmount(raw *big.Int, decimals uint8) Amount {
+	value := new(big.Int)
+	if raw != nil {
+		value.Set(raw)
+	}
+	return Amount{raw: value, decimals: decimals}
+}
+
+// Raw returns the amount in base units
+func (a Amount) Raw() *big.Int {
+	if a.raw == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(a.raw)
+}
+
+// Decimals returns the number of decimals of the amount's asset
+func (a Amount) Decimals() uint8 {
+	return a.decimals
+}
+
+// Add returns the sum of a and b. Amounts of different decimals are combined exactly in the
+// larger of the two.
+func (a Amount) Add(b Amount) Amount {
+	a, b = align(a, b)
+	return Amount{raw: new(big.Int).Add(a.Raw(), b.Raw()), decimals: a.decimals}
+}
+
+// Sub returns the difference of a and b. Amounts of different decimals are combined exactly in
+// the larger of the two.
+func (a Amount) Sub(b Amount) Amount {
+	a, b = align(a, b)
+	return Amount{raw: new(big.Int).Sub(a.Raw(), b.Raw()), decimals: a.decimals}
+}
+
+// align rescales the amount with fewer decimals to the decimals of the other, which is exact as
+// it only multiplies its base units
+func align(a, b Amount) (Amount, Amount) {
+	switch {
+	case a.decimals < b.decimals:
+		a = Amount{raw: new(big.Int).Mul(a.Raw(), pow10(b.decimals-a.decimals)), decimals: b.decimals}
+	case b.decimals < a.decimals:
+		b = Amount{raw: new(big.Int).Mul(b.Raw(), pow10(a.decimals-b.decimals)), decimals: a.decimals}
+	}
+	return a, b
+}
+
+// Abs returns the absolute value of the amount
+func (a Amount) Abs() Amount {
+	return Amount{raw: new(big.Int).Abs(a.Raw()), decimals: a.decimals}
+}
+
+// IsZero reports whether the amount is zero
+func (a Amount) IsZero() bool {
+	return a.raw == nil || a.raw.Sign() == 0
+}
+
+// Rat returns the amount in whole units as an exact rational number
+func (a Amount) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(a.Raw(), pow10(a.decimals))
+}
+
+// Cmp compares the amount in whole units with r
+func (a Amount) Cmp(r *big.Rat) int {
+	return a.Rat().Cmp(r)
+}
+
+// CmpAmount compares two amounts in whole units, regardless of their decimals
+func (a Amount) CmpAmount(b Amount) int {
+	if a.decimals == b.decimals {
+		return a.Raw().Cmp(b.Raw())
+	}
+	return a.Rat().Cmp(b.Rat())
+}
+
+// String returns the exact decimal representation in whole units
+func (a Amount) String() string {
+	return FormatUnits(a.Raw(), a.decimals)
+}
+
+// MarshalJSON encodes the amount as its decimal value, raw base units and decimals
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Value    string `json:"value"`
+		Raw      string `json:"raw"`
+		Decimals uint8  `json:"decimals"`
+	}{
+		Value:    a.String(),
+		Raw:      a.Raw().String(),
+		Decimals: a.decimals,
+	})
+}
+
+// UnmarshalJSON decodes an amount produced by MarshalJSON
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Raw      string `json:"raw"`
+		Decimals uint8  `json:"decimals"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	raw, ok := new(big.Int).SetString(v.Raw, 10)
+	if !ok {
+		return fmt.Errorf("invalid raw amount: %q", v.Raw)
+	}
+
+	*a = Amount{raw: raw, decimals: v.Decimals}
+	return nil
+}
+
+// pow10 returns 10^n as a big.Int
+func pow10(n uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
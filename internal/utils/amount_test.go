@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+// bigInt parses a base 10 integer, failing the test if it is malformed
+func bigInt(t *testing.T, value string) *big.Int {
+	t.Helper()
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		t.Fatalf("invalid integer %q", value)
+	}
+	return n
+}
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		raw      string
+		decimals uint8
+		want     string
+	}{
+		{"0", 0, "0"},
+		{"0", 18, "0"},
+		{"42", 0, "42"},
+		{"1500000000000000000", 18, "1.5"},
+		{"1000000000000000000", 18, "1"},
+		{"1", 18, "0.000000000000000001"},
+		{"123456", 6, "0.123456"},
+		{"1234567", 6, "1.234567"},
+		{"-1500000", 6, "-1.5"},
+		{"-1", 2, "-0.01"},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", 18,
+			"115792089237316195423570985008687907853269984665640564039457.584007913129639935"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatUnits(bigInt(t, tt.raw), tt.decimals); got != tt.want {
+			t.Errorf("FormatUnits(%s, %d) = %q, want %q", tt.raw, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string // Expected rational as a/b, empty if the value is invalid
+	}{
+		{"0", "0/1"},
+		{"12", "12/1"},
+		{"0.05", "1/20"},
+		{"1.000000000000000001", "1000000000000000001/1000000000000000000"},
+		{"007.50", "15/2"},
+		{"", ""},
+		{"-1", ""},
+		{"+1", ""},
+		{"1e18", ""},
+		{".5", ""},
+		{"5.", ""},
+		{"1/2", ""},
+		{"0x10", ""},
+		{" 1", ""},
+		{"1,5", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.value)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseDecimal(%q) = %s, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDecimal(%q) error = %v", tt.value, err)
+		} else if got.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAmountArithmetic(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Amount
+		wantAdd string
+		wantSub string
+		wantDec uint8
+	}{
+		{
+			name:    "same decimals",
+			a:       NewAmount(big.NewInt(1500000), 6),
+			b:       NewAmount(big.NewInt(250000), 6),
+			wantAdd: "1.75",
+			wantSub: "1.25",
+			wantDec: 6,
+		},
+		{
+			name:    "exact beyond float precision",
+			a:       NewAmount(bigInt(t, "100000000000000000000000001"), 18),
+			b:       NewAmount(big.NewInt(1), 18),
+			wantAdd: "100000000.000000000000000002",
+			wantSub: "100000000",
+			wantDec: 18,
+		},
+		{
+			name:    "negative result",
+			a:       NewAmount(big.NewInt(1), 18),
+			b:       NewAmount(big.NewInt(3), 18),
+			wantAdd: "0.000000000000000004",
+			wantSub: "-0.000000000000000002",
+			wantDec: 18,
+		},
+		{
+			name:    "different decimals combine in the larger",
+			a:       NewAmount(big.NewInt(2), 0),
+			b:       NewAmount(big.NewInt(500000), 6),
+			wantAdd: "2.5",
+			wantSub: "1.5",
+			wantDec: 6,
+		},
+		{
+			name:    "zero value",
+			a:       Amount{},
+			b:       NewAmount(big.NewInt(5), 1),
+			wantAdd: "0.5",
+			wantSub: "-0.5",
+			wantDec: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum := tt.a.Add(tt.b)
+			if sum.String() != tt.wantAdd || sum.Decimals() != tt.wantDec {
+				t.Errorf("Add() = %s with %d decimals, want %s with %d", sum, sum.Decimals(), tt.wantAdd, tt.wantDec)
+			}
+			diff := tt.a.Sub(tt.b)
+			if diff.String() != tt.wantSub || diff.Decimals() != tt.wantDec {
+				t.Errorf("Sub() = %s with %d decimals, want %s with %d", diff, diff.Decimals(), tt.wantSub, tt.wantDec)
+			}
+		})
+	}
+}
+
+func TestAmountDoesNotAlias(t *testing.T) {
+	raw := big.NewInt(7)
+	a := NewAmount(raw, 0)
+	raw.SetInt64(9)
+	a.Raw().SetInt64(11)
+	_ = a.Add(NewAmount(big.NewInt(1), 0))
+
+	if a.String() != "7" {
+		t.Errorf("amount = %s, want 7 after mutating its inputs and outputs", a)
+	}
+}
+
+func TestAmountCompare(t *testing.T) {
+	half, _ := ParseDecimal("0.5")
+	a := NewAmount(big.NewInt(500000), 6)
+	b := NewAmount(bigInt(t, "500000000000000000"), 18)
+	c := NewAmount(big.NewInt(1), 0)
+
+	if got := a.CmpAmount(b); got != 0 {
+		t.Errorf("CmpAmount(0.5 with 6 decimals, 0.5 with 18) = %d, want 0", got)
+	}
+	if got := a.CmpAmount(c); got >= 0 {
+		t.Errorf("CmpAmount(0.5, 1) = %d, want < 0", got)
+	}
+	if got := b.Cmp(half); got != 0 {
+		t.Errorf("Cmp(0.5, 0.5) = %d, want 0", got)
+	}
+	if got := NewAmount(big.NewInt(-3), 0).Abs().String(); got != "3" {
+		t.Errorf("Abs(-3) = %s, want 3", got)
+	}
+	if !(Amount{}).IsZero() || c.IsZero() {
+		t.Errorf("IsZero() wrong for the zero value or 1")
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	a := NewAmount(bigInt(t, "1500000000000000000"), 18)
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"value":"1.5","raw":"1500000000000000000","decimals":18}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var decoded Amount
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.CmpAmount(a) != 0 || decoded.Decimals() != 18 {
+		t.Errorf("Unmarshal() = %s with %d decimals, want %s with 18", decoded, decoded.Decimals(), a)
+	}
+
+	if err := json.Unmarshal([]byte(`{"raw":"1.5","decimals":18}`), &decoded); err == nil {
+		t.Errorf("Unmarshal() of a fractional raw amount succeeded, want an error")
+	}
+}
@@ -1,29 +1,50 @@
 package utils
 
 import (
-	"math"
+	"fmt"
 	"math/big"
+	"regexp"
+	"strings"
 	"time"
 )
 
-const weiToEther = 1e18
+// FormatUnits formats a raw integer value as an exact decimal string with the given decimals
+func FormatUnits(raw *big.Int, decimals uint8) string {
+	digits := new(big.Int).Abs(raw).String()
 
-// ConvertWeiToEther converts wei value (string) to ether (float64)
-func ConvertWeiToEther(weiValue string) float64 {
-	valueWei := new(big.Int)
-	valueWei.SetString(weiValue, 10)
-	valueEther := new(big.Float).Quo(new(big.Float).SetInt(valueWei), big.NewFloat(weiToEther))
-	amount, _ := valueEther.Float64()
-	return amount
+	if decimals > 0 {
+		d := int(decimals)
+		if len(digits) <= d {
+			digits = strings.Repeat("0", d-len(digits)+1) + digits
+		}
+
+		intPart := digits[:len(digits)-d]
+		fracPart := strings.TrimRight(digits[len(digits)-d:], "0")
+		digits = intPart
+		if fracPart != "" {
+			digits += "." + fracPart
+		}
+	}
+
+	if raw.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
 }
 
-// ConvertTokenValueWithDecimals converts token value considering its decimals
-func ConvertTokenValueWithDecimals(value string, tokenDecimal uint8) float64 {
-	divisor := new(big.Float).SetFloat64(math.Pow10(int(tokenDecimal)))
-	valueToken := new(big.Float)
-	valueToken.SetString(value)
-	amount, _ := new(big.Float).Quo(valueToken, divisor).Float64()
-	return amount
+// decimalPattern matches a non-negative decimal number without exponent, such as "12" or "0.05"
+var decimalPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// ParseDecimal parses a non-negative decimal string such as "0.05" into an exact rational number
+func ParseDecimal(value string) (*big.Rat, error) {
+	if !decimalPattern.MatchString(value) {
+		return nil, fmt.Errorf("invalid decimal amount: %q", value)
+	}
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal amount: %q", value)
+	}
+	return r, nil
 }
 
 // FormatTimestamp converts Unix timestamp to formatted datetime string